@@ -0,0 +1,175 @@
+package gorm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	authjwt "github.com/unkmonster/go-kit/auth/jwt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ authjwt.RevocationStore = (*RevocationStore)(nil)
+
+const (
+	kindToken   = "jti"
+	kindSubject = "sub"
+)
+
+// neverExpires 代替零值的 expiresAt，与 authjwt.MemoryRevocationStore 一致表示记录永不过期，
+// 同时在 MySQL 的 DATETIME 范围内
+var neverExpires = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// expiry 返回写入 expires_at 的时间
+func expiry(expiresAt time.Time) time.Time {
+	if expiresAt.IsZero() {
+		return neverExpires
+	}
+	return expiresAt.UTC()
+}
+
+// revocationRecord 作废表中的一行，kind 为 jti 时 name 为 token 的 jti，
+// 为 sub 时 name 为 subject，cutoff 为作废的截止时间
+type revocationRecord struct {
+	Kind      string    `gorm:"primaryKey;size:8"`
+	Name      string    `gorm:"primaryKey;size:191"`
+	Cutoff    time.Time `gorm:"not null"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
+
+type options struct {
+	table string
+}
+
+type Option func(o *options)
+
+// WithTable 指定作废表的名称，默认为 jwt_revocations
+func WithTable(table string) Option {
+	return func(o *options) {
+		o.table = table
+	}
+}
+
+// RevocationStore 基于 GORM 的 authjwt.RevocationStore
+type RevocationStore struct {
+	db    *gorm.DB
+	table string
+}
+
+func NewRevocationStore(db *gorm.DB, opts ...Option) *RevocationStore {
+	o := &options{
+		table: "jwt_revocations",
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return &RevocationStore{
+		db:    db,
+		table: o.table,
+	}
+}
+
+// Migrate 创建或更新作废表
+func (s *RevocationStore) Migrate(ctx context.Context) error {
+	return s.db.WithContext(ctx).Table(s.table).AutoMigrate(&revocationRecord{})
+}
+
+// RevokeToken implements authjwt.RevocationStore.
+func (s *RevocationStore) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	// jti 记录不使用 cutoff，写入 expiresAt 避免零值时间
+	if err := s.upsert(ctx, kindToken, jti, expiry(expiresAt), expiresAt); err != nil {
+		return fmt.Errorf("revoke token: %w", err)
+	}
+	return nil
+}
+
+// RevokeTokenOnce implements authjwt.RevocationStore.
+func (s *RevocationStore) RevokeTokenOnce(ctx context.Context, jti string, expiresAt time.Time) (bool, error) {
+	expiresAt = expiry(expiresAt)
+	record := &revocationRecord{Kind: kindToken, Name: jti, Cutoff: expiresAt, ExpiresAt: expiresAt}
+	result := s.db.WithContext(ctx).Table(s.table).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(record)
+	if result.Error != nil {
+		return false, fmt.Errorf("revoke token: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+
+	// 记录已经存在但是过期且尚未被 Cleanup 删除时，只有一个调用者能够更新成功
+	result = s.db.WithContext(ctx).Table(s.table).
+		Where("kind = ? AND name = ? AND expires_at <= ?", kindToken, jti, time.Now().UTC()).
+		Updates(map[string]any{"cutoff": record.Cutoff, "expires_at": record.ExpiresAt})
+	if result.Error != nil {
+		return false, fmt.Errorf("revoke token: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// RevokeSubject implements authjwt.RevocationStore.
+func (s *RevocationStore) RevokeSubject(ctx context.Context, subject string, cutoff, expiresAt time.Time) error {
+	if err := s.upsert(ctx, kindSubject, subject, cutoff, expiresAt); err != nil {
+		return fmt.Errorf("revoke subject: %w", err)
+	}
+	return nil
+}
+
+// IsRevoked implements authjwt.RevocationStore.
+func (s *RevocationStore) IsRevoked(ctx context.Context, jti, subject string, issuedAt time.Time) (bool, error) {
+	if jti == "" && subject == "" {
+		return false, nil
+	}
+
+	cond := s.db.Where("1 = 0")
+	if jti != "" {
+		cond = cond.Or("kind = ? AND name = ?", kindToken, jti)
+	}
+	if subject != "" {
+		// 与 authjwt.MemoryRevocationStore 一致，在 cutoff 及之前签发的 token 被作废
+		cond = cond.Or("kind = ? AND name = ? AND cutoff >= ?", kindSubject, subject, issuedAt.UTC())
+	}
+
+	var count int64
+	err := s.db.WithContext(ctx).Table(s.table).
+		Where(cond).
+		Where("expires_at > ?", time.Now().UTC()).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("check revocation: %w", err)
+	}
+	return count > 0, nil
+}
+
+// Cleanup 删除已经过期的记录，可以作为周期任务运行
+func (s *RevocationStore) Cleanup(ctx context.Context) error {
+	err := s.db.WithContext(ctx).Table(s.table).
+		Where("expires_at <= ?", time.Now().UTC()).
+		Delete(&revocationRecord{}).Error
+	if err != nil {
+		return fmt.Errorf("cleanup revocations: %w", err)
+	}
+	return nil
+}
+
+// upsert 写入记录，记录已经存在时与 authjwt.MemoryRevocationStore 一致保留较大的 cutoff 和 expires_at，
+// 避免较早的 RevokeSubject 缩小已经作废的范围
+func (s *RevocationStore) upsert(ctx context.Context, kind, name string, cutoff, expiresAt time.Time) error {
+	cutoff, expiresAt = cutoff.UTC(), expiry(expiresAt)
+	return s.db.WithContext(ctx).Table(s.table).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "kind"}, {Name: "name"}},
+			DoUpdates: clause.Set{
+				{Column: clause.Column{Name: "cutoff"}, Value: greatest("cutoff", cutoff)},
+				{Column: clause.Column{Name: "expires_at"}, Value: greatest("expires_at", expiresAt)},
+			},
+		}).
+		Create(&revocationRecord{Kind: kind, Name: name, Cutoff: cutoff, ExpiresAt: expiresAt}).Error
+}
+
+// greatest 返回 column 与 t 中较大的值，SQLite 没有 GREATEST，使用 CASE 兼容不同的数据库
+func greatest(column string, t time.Time) clause.Expr {
+	return gorm.Expr("CASE WHEN "+column+" < ? THEN ? ELSE "+column+" END", t, t)
+}
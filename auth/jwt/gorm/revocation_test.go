@@ -0,0 +1,152 @@
+package gorm
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newRevocationStore(t *testing.T) *RevocationStore {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "revocation.db")), &gorm.Config{})
+	require.NoError(t, err)
+
+	store := NewRevocationStore(db)
+	require.NoError(t, store.Migrate(context.Background()))
+	return store
+}
+
+func TestRevokeToken(t *testing.T) {
+	store := newRevocationStore(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	revoked, err := store.IsRevoked(ctx, "a", "user", now)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	require.NoError(t, store.RevokeToken(ctx, "a", now.Add(time.Hour)))
+	// 重复作废不会出错
+	require.NoError(t, store.RevokeToken(ctx, "a", now.Add(time.Hour)))
+
+	revoked, err = store.IsRevoked(ctx, "a", "user", now)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	revoked, err = store.IsRevoked(ctx, "b", "user", now)
+	require.NoError(t, err)
+	require.False(t, revoked)
+}
+
+func TestRevokeSubject(t *testing.T) {
+	store := newRevocationStore(t)
+	ctx := context.Background()
+	cutoff := time.Now()
+
+	require.NoError(t, store.RevokeSubject(ctx, "user", cutoff, cutoff.Add(time.Hour)))
+
+	revoked, err := store.IsRevoked(ctx, "a", "user", cutoff.Add(-time.Minute))
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	// cutoff 之后签发的 token 不受影响
+	revoked, err = store.IsRevoked(ctx, "a", "user", cutoff.Add(time.Second))
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	revoked, err = store.IsRevoked(ctx, "a", "other", cutoff.Add(-time.Minute))
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	// 更新 cutoff
+	require.NoError(t, store.RevokeSubject(ctx, "user", cutoff.Add(time.Minute), cutoff.Add(time.Hour)))
+	revoked, err = store.IsRevoked(ctx, "a", "user", cutoff.Add(time.Second))
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	// 较早的 cutoff 和 expiresAt 不会缩小已经作废的范围
+	require.NoError(t, store.RevokeSubject(ctx, "user", cutoff, cutoff.Add(time.Second)))
+	revoked, err = store.IsRevoked(ctx, "a", "user", cutoff.Add(time.Second))
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	var record revocationRecord
+	require.NoError(t, store.db.Table(store.table).Where("kind = ? AND name = ?", kindSubject, "user").Take(&record).Error)
+	require.WithinDuration(t, cutoff.Add(time.Minute), record.Cutoff, time.Millisecond)
+	require.WithinDuration(t, cutoff.Add(time.Hour), record.ExpiresAt, time.Millisecond)
+}
+
+func TestRevokeWithoutExpiry(t *testing.T) {
+	store := newRevocationStore(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	// 零值的 expiresAt 表示永不过期
+	require.NoError(t, store.RevokeToken(ctx, "a", time.Time{}))
+	require.NoError(t, store.RevokeSubject(ctx, "user", now, time.Time{}))
+	require.NoError(t, store.Cleanup(ctx))
+
+	revoked, err := store.IsRevoked(ctx, "a", "", now)
+	require.NoError(t, err)
+	require.True(t, revoked)
+	revoked, err = store.IsRevoked(ctx, "", "user", now.Add(-time.Minute))
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	won, err := store.RevokeTokenOnce(ctx, "b", time.Time{})
+	require.NoError(t, err)
+	require.True(t, won)
+	revoked, err = store.IsRevoked(ctx, "b", "", now)
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
+
+func TestRevocationCleanup(t *testing.T) {
+	store := newRevocationStore(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	require.NoError(t, store.RevokeToken(ctx, "expired", now.Add(-time.Second)))
+	require.NoError(t, store.RevokeToken(ctx, "active", now.Add(time.Hour)))
+
+	// 过期记录不再生效
+	revoked, err := store.IsRevoked(ctx, "expired", "", now)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	require.NoError(t, store.Cleanup(ctx))
+
+	var count int64
+	require.NoError(t, store.db.Table(store.table).Count(&count).Error)
+	require.EqualValues(t, 1, count)
+}
+
+func TestRevokeTokenOnce(t *testing.T) {
+	store := newRevocationStore(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	won, err := store.RevokeTokenOnce(ctx, "a", now.Add(time.Hour))
+	require.NoError(t, err)
+	require.True(t, won)
+	won, err = store.RevokeTokenOnce(ctx, "a", now.Add(time.Hour))
+	require.NoError(t, err)
+	require.False(t, won)
+
+	revoked, err := store.IsRevoked(ctx, "a", "", now)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	// 过期但尚未清理的记录视为不存在
+	require.NoError(t, store.RevokeToken(ctx, "expired", now.Add(-time.Second)))
+	won, err = store.RevokeTokenOnce(ctx, "expired", now.Add(time.Hour))
+	require.NoError(t, err)
+	require.True(t, won)
+	revoked, err = store.IsRevoked(ctx, "expired", "", now)
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
@@ -0,0 +1,22 @@
+package jwt
+
+import (
+	nethttp "net/http"
+
+	"github.com/go-kratos/kratos/v2/transport/http"
+)
+
+// JWKSPath 公开 JWKS 的标准路径
+const JWKSPath = "/.well-known/jwks.json"
+
+// JWKSHandler 返回以 JSON 格式输出 KeyRing 公钥的 Kratos HTTP handler
+func (r *KeyRing) JWKSHandler() http.HandlerFunc {
+	return func(ctx http.Context) error {
+		return ctx.JSON(nethttp.StatusOK, r.JWKS())
+	}
+}
+
+// RegisterJWKSHandler 在 srv 的 JWKSPath 上注册 r.JWKSHandler
+func RegisterJWKSHandler(srv *http.Server, r *KeyRing) {
+	srv.Route("/").GET(JWKSPath, r.JWKSHandler())
+}
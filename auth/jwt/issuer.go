@@ -0,0 +1,303 @@
+package jwt
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// TokenTypeClaim 区分 access token 和 refresh token 的自定义 claim
+	TokenTypeClaim = "token_type"
+
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+// ErrInvalidRefreshToken refresh token 无效、已过期或者不是 refresh token
+var ErrInvalidRefreshToken = errors.New("jwt: invalid refresh token")
+
+// KeySource 为 Issuer 提供签名密钥和验证密钥，KeyRing 实现了该接口
+type KeySource interface {
+	// Current 返回当前签名密钥
+	Current() (SigningKey, error)
+	// KeyFunc 根据 token 选择验证密钥
+	KeyFunc(token *jwt.Token) (any, error)
+}
+
+type staticKey struct {
+	key SigningKey
+}
+
+// StaticKey 使用固定的签名密钥作为 KeySource
+func StaticKey(key SigningKey) KeySource {
+	return staticKey{key: key}
+}
+
+func (s staticKey) Current() (SigningKey, error) {
+	return s.key, nil
+}
+
+func (s staticKey) KeyFunc(token *jwt.Token) (any, error) {
+	if s.key.Method.Alg() != token.Method.Alg() {
+		return nil, fmt.Errorf("jwt: unexpected signing method %s", token.Method.Alg())
+	}
+	return s.key.VerifyKey(), nil
+}
+
+type issuerOptions struct {
+	issuer      string
+	audience    []string
+	ttl         time.Duration
+	refreshTTL  time.Duration
+	notBefore   time.Duration
+	idGenerator func() string
+	onRefresh   func(ctx context.Context, claims *jwt.RegisteredClaims) error
+	revocation  RevocationStore
+}
+
+// IssuerOption 配置 Issuer
+type IssuerOption func(o *issuerOptions)
+
+// WithIssuer 指定 token 的 iss
+func WithIssuer(iss string) IssuerOption {
+	return func(o *issuerOptions) {
+		o.issuer = iss
+	}
+}
+
+// WithAudience 指定 token 的 aud
+func WithAudience(aud ...string) IssuerOption {
+	return func(o *issuerOptions) {
+		o.audience = aud
+	}
+}
+
+// WithTTL 指定 access token 的有效期，默认为 15 分钟
+func WithTTL(ttl time.Duration) IssuerOption {
+	return func(o *issuerOptions) {
+		o.ttl = ttl
+	}
+}
+
+// WithRefreshTTL 指定 refresh token 的有效期，默认为 7 天
+func WithRefreshTTL(ttl time.Duration) IssuerOption {
+	return func(o *issuerOptions) {
+		o.refreshTTL = ttl
+	}
+}
+
+// WithNotBefore 指定 token 在签发后多久开始生效，默认立即生效
+func WithNotBefore(d time.Duration) IssuerOption {
+	return func(o *issuerOptions) {
+		o.notBefore = d
+	}
+}
+
+// WithIDGenerator 指定 jti 的生成方式，默认为 crypto/rand.Text
+func WithIDGenerator(f func() string) IssuerOption {
+	return func(o *issuerOptions) {
+		o.idGenerator = f
+	}
+}
+
+// WithRefreshCheck 指定 Refresh 签发新 token 前对旧 refresh token 的额外检查，
+// 例如检查其是否已经被使用过，返回错误时 Refresh 失败
+func WithRefreshCheck(f func(ctx context.Context, claims *jwt.RegisteredClaims) error) IssuerOption {
+	return func(o *issuerOptions) {
+		o.onRefresh = f
+	}
+}
+
+// WithRevocationStore 指定 Refresh 使用的 RevocationStore，
+// 已经作废的 refresh token 不能用来刷新，使用过的 refresh token 会被作废
+func WithRevocationStore(store RevocationStore) IssuerOption {
+	return func(o *issuerOptions) {
+		o.revocation = store
+	}
+}
+
+// Issuer 签发 token，所有方法都是线程安全的
+type Issuer struct {
+	keys KeySource
+	o    *issuerOptions
+}
+
+func NewIssuer(keys KeySource, opts ...IssuerOption) *Issuer {
+	o := &issuerOptions{
+		ttl:         15 * time.Minute,
+		refreshTTL:  7 * 24 * time.Hour,
+		idGenerator: rand.Text,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &Issuer{keys: keys, o: o}
+}
+
+// IssuedToken 签发的 token 及其元信息
+type IssuedToken struct {
+	Token     string
+	ID        string
+	ExpiresAt time.Time
+}
+
+// TokenPair access token 和 refresh token
+type TokenPair struct {
+	Access  IssuedToken
+	Refresh IssuedToken
+}
+
+// Registered 为 subject 构造有效期为 ttl 的 RegisteredClaims，
+// 可以嵌入自定义 claims 后通过 Sign 签名
+func (i *Issuer) Registered(subject string, ttl time.Duration) jwt.RegisteredClaims {
+	now := time.Now()
+	claims := jwt.RegisteredClaims{
+		Issuer:    i.o.issuer,
+		Subject:   subject,
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		NotBefore: jwt.NewNumericDate(now.Add(i.o.notBefore)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ID:        i.o.idGenerator(),
+	}
+	if len(i.o.audience) > 0 {
+		claims.Audience = jwt.ClaimStrings(i.o.audience)
+	}
+	return claims
+}
+
+// Sign 使用当前签名密钥签名 claims，并在 header 中设置 kid
+func (i *Issuer) Sign(claims jwt.Claims) (string, error) {
+	key, err := i.keys.Current()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	if key.Kid != "" {
+		token.Header["kid"] = key.Kid
+	}
+	s, err := token.SignedString(key.Key)
+	if err != nil {
+		return "", fmt.Errorf("sign token: %w", err)
+	}
+	return s, nil
+}
+
+// Issue 为 subject 签发 access token，custom 中的 claim 不会覆盖 registered claims
+func (i *Issuer) Issue(subject string, custom map[string]any) (IssuedToken, error) {
+	return i.issue(subject, i.o.ttl, TokenTypeAccess, custom)
+}
+
+// IssuePair 为 subject 签发 access token 和 refresh token，custom 只写入 access token
+func (i *Issuer) IssuePair(subject string, custom map[string]any) (TokenPair, error) {
+	access, err := i.issue(subject, i.o.ttl, TokenTypeAccess, custom)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	refresh, err := i.issue(subject, i.o.refreshTTL, TokenTypeRefresh, nil)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	return TokenPair{Access: access, Refresh: refresh}, nil
+}
+
+// VerifyRefresh 验证 refresh token 并返回其 claims
+func (i *Issuer) VerifyRefresh(refreshToken string) (*jwt.RegisteredClaims, error) {
+	opts := []jwt.ParserOption{jwt.WithExpirationRequired()}
+	if i.o.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(i.o.issuer))
+	}
+	if len(i.o.audience) > 0 {
+		opts = append(opts, jwt.WithAudience(i.o.audience[0]))
+	}
+
+	claims := refreshClaims{}
+	if _, err := jwt.ParseWithClaims(refreshToken, &claims, i.keys.KeyFunc, opts...); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRefreshToken, err)
+	}
+	if claims.TokenType != TokenTypeRefresh {
+		return nil, fmt.Errorf("%w: unexpected token type %q", ErrInvalidRefreshToken, claims.TokenType)
+	}
+	return &claims.RegisteredClaims, nil
+}
+
+// Refresh 验证 refresh token 并为其 subject 签发新的 token 对，
+// 指定了 WithRevocationStore 时旧的 refresh token 会被作废，避免被重复使用
+func (i *Issuer) Refresh(ctx context.Context, refreshToken string, custom map[string]any) (TokenPair, error) {
+	claims, err := i.VerifyRefresh(refreshToken)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	if store := i.o.revocation; store != nil {
+		var issuedAt time.Time
+		if claims.IssuedAt != nil {
+			issuedAt = claims.IssuedAt.Time
+		}
+		revoked, err := store.IsRevoked(ctx, claims.ID, claims.Subject, issuedAt)
+		if err != nil {
+			return TokenPair{}, err
+		}
+		if revoked {
+			return TokenPair{}, fmt.Errorf("%w: %w", ErrInvalidRefreshToken, ErrTokenRevoked)
+		}
+	}
+	if i.o.onRefresh != nil {
+		if err := i.o.onRefresh(ctx, claims); err != nil {
+			return TokenPair{}, err
+		}
+	}
+	if store := i.o.revocation; store != nil {
+		// IsRevoked 与作废之间可能有并发的 Refresh，只有成功作废旧 token 的调用者可以签发新的 token 对
+		won, err := store.RevokeTokenOnce(ctx, claims.ID, claims.ExpiresAt.Time)
+		if err != nil {
+			return TokenPair{}, err
+		}
+		if !won {
+			return TokenPair{}, fmt.Errorf("%w: %w", ErrInvalidRefreshToken, ErrTokenRevoked)
+		}
+	}
+	return i.IssuePair(claims.Subject, custom)
+}
+
+func (i *Issuer) issue(subject string, ttl time.Duration, typ string, custom map[string]any) (IssuedToken, error) {
+	registered := i.Registered(subject, ttl)
+
+	claims := jwt.MapClaims{}
+	maps.Copy(claims, custom)
+	maps.Copy(claims, registeredToMap(registered))
+	claims[TokenTypeClaim] = typ
+
+	s, err := i.Sign(claims)
+	if err != nil {
+		return IssuedToken{}, err
+	}
+	return IssuedToken{Token: s, ID: registered.ID, ExpiresAt: registered.ExpiresAt.Time}, nil
+}
+
+func registeredToMap(c jwt.RegisteredClaims) jwt.MapClaims {
+	m := jwt.MapClaims{
+		"sub": c.Subject,
+		"exp": c.ExpiresAt.Unix(),
+		"nbf": c.NotBefore.Unix(),
+		"iat": c.IssuedAt.Unix(),
+		"jti": c.ID,
+	}
+	if c.Issuer != "" {
+		m["iss"] = c.Issuer
+	}
+	if len(c.Audience) > 0 {
+		m["aud"] = []string(c.Audience)
+	}
+	return m
+}
+
+type refreshClaims struct {
+	jwt.RegisteredClaims
+	TokenType string `json:"token_type"`
+}
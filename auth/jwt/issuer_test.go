@@ -0,0 +1,111 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestIssuer(t *testing.T, opts ...IssuerOption) (*Issuer, *KeyRing) {
+	ring := NewKeyRing(WithKeyRingMethod("ES256"))
+	_, err := ring.Rotate()
+	require.NoError(t, err)
+	opts = append([]IssuerOption{WithIssuer("auth"), WithAudience("api")}, opts...)
+	return NewIssuer(ring, opts...), ring
+}
+
+func TestIssue(t *testing.T) {
+	iss, ring := newTestIssuer(t, WithTTL(time.Minute))
+
+	token, err := iss.Issue("u1", map[string]any{"role": "admin", "sub": "ignored"})
+	require.NoError(t, err)
+	require.NotEmpty(t, token.ID)
+	require.WithinDuration(t, time.Now().Add(time.Minute), token.ExpiresAt, time.Second)
+
+	claims := jwt.MapClaims{}
+	parsed, err := jwt.ParseWithClaims(token.Token, claims, ring.KeyFunc,
+		jwt.WithIssuer("auth"), jwt.WithAudience("api"), jwt.WithExpirationRequired())
+	require.NoError(t, err)
+
+	current, err := ring.Current()
+	require.NoError(t, err)
+	require.Equal(t, current.Kid, parsed.Header["kid"])
+	require.Equal(t, "u1", claims["sub"])
+	require.Equal(t, "admin", claims["role"])
+	require.Equal(t, token.ID, claims["jti"])
+	require.Equal(t, TokenTypeAccess, claims[TokenTypeClaim])
+}
+
+func TestIssueNotBefore(t *testing.T) {
+	iss, ring := newTestIssuer(t, WithNotBefore(time.Hour))
+
+	token, err := iss.Issue("u1", nil)
+	require.NoError(t, err)
+	_, err = jwt.Parse(token.Token, ring.KeyFunc)
+	require.ErrorIs(t, err, jwt.ErrTokenNotValidYet)
+}
+
+func TestSignCustomClaims(t *testing.T) {
+	type customClaims struct {
+		jwt.RegisteredClaims
+		Role string `json:"role"`
+	}
+
+	key, err := NewSigningKey("HS256")
+	require.NoError(t, err)
+	iss := NewIssuer(StaticKey(key), WithIDGenerator(func() string { return "fixed" }))
+
+	s, err := iss.Sign(customClaims{RegisteredClaims: iss.Registered("u1", time.Minute), Role: "admin"})
+	require.NoError(t, err)
+
+	claims := &customClaims{}
+	_, err = jwt.ParseWithClaims(s, claims, StaticKey(key).KeyFunc)
+	require.NoError(t, err)
+	require.Equal(t, "u1", claims.Subject)
+	require.Equal(t, "fixed", claims.ID)
+	require.Equal(t, "admin", claims.Role)
+}
+
+func TestRefresh(t *testing.T) {
+	used := map[string]bool{}
+	iss, ring := newTestIssuer(t, WithRefreshCheck(func(ctx context.Context, claims *jwt.RegisteredClaims) error {
+		if used[claims.ID] {
+			return errors.New("refresh token reused")
+		}
+		used[claims.ID] = true
+		return nil
+	}))
+
+	pair, err := iss.IssuePair("u1", map[string]any{"role": "admin"})
+	require.NoError(t, err)
+	require.NotEqual(t, pair.Access.ID, pair.Refresh.ID)
+
+	// access token 不能用来刷新
+	_, err = iss.Refresh(context.Background(), pair.Access.Token, nil)
+	require.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+	// 密钥轮换后旧密钥签发的 refresh token 仍然有效
+	_, err = ring.Rotate()
+	require.NoError(t, err)
+
+	next, err := iss.Refresh(context.Background(), pair.Refresh.Token, map[string]any{"role": "user"})
+	require.NoError(t, err)
+	claims, err := iss.VerifyRefresh(next.Refresh.Token)
+	require.NoError(t, err)
+	require.Equal(t, "u1", claims.Subject)
+
+	// 旧的 refresh token 已经被使用过
+	_, err = iss.Refresh(context.Background(), pair.Refresh.Token, nil)
+	require.EqualError(t, err, "refresh token reused")
+
+	// 其他 issuer 签发的 refresh token
+	other, _ := newTestIssuer(t)
+	foreign, err := other.IssuePair("u1", nil)
+	require.NoError(t, err)
+	_, err = iss.Refresh(context.Background(), foreign.Refresh.Token, nil)
+	require.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
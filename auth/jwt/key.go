@@ -1,8 +1,12 @@
 package jwt
 
 import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"encoding/base64"
 	"fmt"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -17,12 +21,68 @@ func GenerateRS256KeyPairPem() (pub, priv string, err error) {
 		return "", "", fmt.Errorf("generate private key: %w", err)
 	}
 
+	return encodeKeyPairPem(privKey, &privKey.PublicKey)
+}
+
+// GenerateSigningKey 根据 alg 生成一个 secret 或者 pem 格式的密钥对，
+// 支持 HMAC、RSA、RSA-PSS、ECDSA 和 EdDSA
+func GenerateSigningKey(method string) (pubKey, privKey, secret *string, err error) {
+	alg := jwt.GetSigningMethod(method)
+	if alg == nil {
+		return nil, nil, nil, fmt.Errorf("invalid signing method: %s", method)
+	}
+
+	var pub, priv string
+	switch m := alg.(type) {
+	case *jwt.SigningMethodHMAC:
+		b := make([]byte, m.Hash.Size())
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, nil, fmt.Errorf("generate secret: %w", err)
+		}
+		s := base64.RawURLEncoding.EncodeToString(b)
+		return nil, nil, &s, nil
+
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		pub, priv, err = GenerateRS256KeyPairPem()
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("GenerateRS256KeyPairPem: %w", err)
+		}
+
+	case *jwt.SigningMethodECDSA:
+		curve, err := ecdsaCurve(m)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		k, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("generate private key: %w", err)
+		}
+		if pub, priv, err = encodeKeyPairPem(k, &k.PublicKey); err != nil {
+			return nil, nil, nil, err
+		}
+
+	case *jwt.SigningMethodEd25519:
+		pk, k, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("generate private key: %w", err)
+		}
+		if pub, priv, err = encodeKeyPairPem(k, pk); err != nil {
+			return nil, nil, nil, err
+		}
+
+	default:
+		return nil, nil, nil, fmt.Errorf("unsupported signing method: %s", alg.Alg())
+	}
+	return &pub, &priv, nil, nil
+}
+
+func encodeKeyPairPem(privKey, pubKey any) (pub, priv string, err error) {
 	privBytes, err := pem.EncodePKCS8PrivateKey(privKey)
 	if err != nil {
 		return "", "", fmt.Errorf("EncodePKCS8PrivateKey: %w", err)
 	}
 
-	pubBytes, err := pem.EncodePKIXPublicKey(&privKey.PublicKey)
+	pubBytes, err := pem.EncodePKIXPublicKey(pubKey)
 	if err != nil {
 		return "", "", fmt.Errorf("EncodePKIXPublicKey: %w", err)
 	}
@@ -30,20 +90,14 @@ func GenerateRS256KeyPairPem() (pub, priv string, err error) {
 	return string(pubBytes), string(privBytes), nil
 }
 
-// GenerateSigningKey 根据 alg 生成一个 secret 或者 pem 格式的密钥对
-func GenerateSigningKey(method string) (pubKey, privKey, secret *string, err error) {
-	alg := jwt.GetSigningMethod(method)
-	if alg == nil {
-		return nil, nil, nil, fmt.Errorf("invalid signing method: %s", method)
-	}
-
-	if alg == jwt.SigningMethodRS256 {
-		pub, priv, err := GenerateRS256KeyPairPem()
-		if err != nil {
-			return nil, nil, nil, fmt.Errorf("GenerateRS256KeyPairPem: %w", err)
-		}
-		return &pub, &priv, nil, nil
+func ecdsaCurve(m *jwt.SigningMethodECDSA) (elliptic.Curve, error) {
+	switch m.CurveBits {
+	case 256:
+		return elliptic.P256(), nil
+	case 384:
+		return elliptic.P384(), nil
+	case 521:
+		return elliptic.P521(), nil
 	}
-	err = fmt.Errorf("unsupported signing method: %s", alg.Alg())
-	return
+	return nil, fmt.Errorf("unsupported curve for %s", m.Alg())
 }
@@ -0,0 +1,353 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/unkmonster/go-kit/crypto/x509util"
+)
+
+var (
+	// ErrNoSigningKey KeyRing 中没有当前签名密钥
+	ErrNoSigningKey = errors.New("jwt: no current signing key")
+	// ErrKeyNotFound KeyRing 中没有指定 kid 的密钥
+	ErrKeyNotFound = errors.New("jwt: signing key not found")
+)
+
+// SigningKey 带有 kid 的签名密钥
+type SigningKey struct {
+	Kid    string
+	Method jwt.SigningMethod
+	// HMAC 为 []byte，其他算法为 *rsa.PrivateKey、*ecdsa.PrivateKey 或 ed25519.PrivateKey
+	Key       any
+	CreatedAt time.Time
+	// 密钥被轮换下来的时间，零值表示仍然有效
+	RetiredAt time.Time
+}
+
+// NewSigningKey 使用 GenerateSigningKey 生成指定算法的密钥，kid 随机生成
+func NewSigningKey(method string) (SigningKey, error) {
+	_, priv, secret, err := GenerateSigningKey(method)
+	if err != nil {
+		return SigningKey{}, err
+	}
+
+	kid, err := randomKid()
+	if err != nil {
+		return SigningKey{}, err
+	}
+
+	if secret != nil {
+		return ParseSigningKey(kid, method, *secret)
+	}
+	return ParseSigningKey(kid, method, *priv)
+}
+
+// ParseSigningKey 解析 GenerateSigningKey 生成的 secret 或者 PEM 格式的私钥
+func ParseSigningKey(kid, method, key string) (SigningKey, error) {
+	alg := jwt.GetSigningMethod(method)
+	if alg == nil {
+		return SigningKey{}, fmt.Errorf("invalid signing method: %s", method)
+	}
+
+	k := SigningKey{Kid: kid, Method: alg, CreatedAt: time.Now()}
+	if _, ok := alg.(*jwt.SigningMethodHMAC); ok {
+		k.Key = []byte(key)
+		return k, nil
+	}
+
+	priv, err := x509util.DecodePKCS8PrivateKey([]byte(key))
+	if err != nil {
+		return SigningKey{}, fmt.Errorf("DecodePKCS8PrivateKey: %w", err)
+	}
+	k.Key = priv
+	return k, nil
+}
+
+// Public 返回公钥，HMAC 密钥返回 nil
+func (k SigningKey) Public() crypto.PublicKey {
+	if signer, ok := k.Key.(crypto.Signer); ok {
+		return signer.Public()
+	}
+	return nil
+}
+
+// VerifyKey 返回验证签名使用的密钥
+func (k SigningKey) VerifyKey() any {
+	if pub := k.Public(); pub != nil {
+		return pub
+	}
+	return k.Key
+}
+
+// Retired 判断密钥是否已经被轮换下来
+func (k SigningKey) Retired() bool {
+	return !k.RetiredAt.IsZero()
+}
+
+func randomKid() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate kid: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+type keyRingOptions struct {
+	method    string
+	interval  time.Duration
+	retention time.Duration
+}
+
+// KeyRingOption 配置 KeyRing
+type KeyRingOption func(o *keyRingOptions)
+
+// WithKeyRingMethod 指定 Rotate 生成密钥使用的算法，默认为 RS256
+func WithKeyRingMethod(method string) KeyRingOption {
+	return func(o *keyRingOptions) {
+		o.method = method
+	}
+}
+
+// WithRotationInterval 指定 Run 轮换密钥的间隔，默认为 24 小时，不是正数时使用默认值
+func WithRotationInterval(d time.Duration) KeyRingOption {
+	return func(o *keyRingOptions) {
+		o.interval = d
+	}
+}
+
+// WithKeyRetention 指定密钥被轮换下来后继续保留多久，
+// 应当大于签发的 token 的最长有效期，默认与轮换间隔相同，不是正数时使用默认值
+func WithKeyRetention(d time.Duration) KeyRingOption {
+	return func(o *keyRingOptions) {
+		o.retention = d
+	}
+}
+
+// KeyRing 保存多个签名密钥，其中一个为当前签名密钥，
+// 被轮换下来的密钥在保留期内仍然可以用来验证 token。所有方法都是线程安全的
+type KeyRing struct {
+	o *keyRingOptions
+
+	mu      sync.RWMutex
+	keys    []SigningKey
+	current string
+}
+
+func NewKeyRing(opts ...KeyRingOption) *KeyRing {
+	o := &keyRingOptions{
+		method:   jwt.SigningMethodRS256.Alg(),
+		interval: 24 * time.Hour,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.interval <= 0 {
+		o.interval = 24 * time.Hour
+	}
+	if o.retention <= 0 {
+		o.retention = o.interval
+	}
+	return &KeyRing{o: o}
+}
+
+// Add 添加密钥，current 为 true 时将其设为当前签名密钥，原来的签名密钥会被轮换下来，
+// 同时清理超过保留期的密钥
+func (r *KeyRing) Add(key SigningKey, current bool) error {
+	if key.Kid == "" {
+		return fmt.Errorf("jwt: signing key without kid")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.indexLocked(key.Kid) >= 0 {
+		return fmt.Errorf("jwt: duplicate kid %q", key.Kid)
+	}
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now()
+	}
+	r.keys = append(r.keys, key)
+	if current {
+		r.setCurrentLocked(key.Kid)
+	}
+	r.pruneLocked()
+	return nil
+}
+
+// Rotate 生成新的密钥并设为当前签名密钥，同时清理超过保留期的密钥
+func (r *KeyRing) Rotate() (SigningKey, error) {
+	key, err := NewSigningKey(r.o.method)
+	if err != nil {
+		return SigningKey{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.keys = append(r.keys, key)
+	r.setCurrentLocked(key.Kid)
+	r.pruneLocked()
+	return key, nil
+}
+
+// Retire 将密钥轮换下来，如果它是当前签名密钥，KeyRing 将没有签名密钥直到下一次 Add 或 Rotate，
+// 同时清理超过保留期的密钥
+func (r *KeyRing) Retire(kid string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexLocked(kid)
+	if i < 0 {
+		return ErrKeyNotFound
+	}
+	if !r.keys[i].Retired() {
+		r.keys[i].RetiredAt = time.Now()
+	}
+	if r.current == kid {
+		r.current = ""
+	}
+	r.pruneLocked()
+	return nil
+}
+
+// Current 返回当前签名密钥
+func (r *KeyRing) Current() (SigningKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if i := r.indexLocked(r.current); i >= 0 {
+		return r.keys[i], nil
+	}
+	return SigningKey{}, ErrNoSigningKey
+}
+
+// Key 返回指定 kid 的密钥，超过保留期的密钥视为不存在
+func (r *KeyRing) Key(kid string) (SigningKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if i := r.indexLocked(kid); i >= 0 && !r.expired(r.keys[i], time.Now()) {
+		return r.keys[i], nil
+	}
+	return SigningKey{}, ErrKeyNotFound
+}
+
+// Keys 返回所有仍在保留期内的密钥
+func (r *KeyRing) Keys() []SigningKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	keys := make([]SigningKey, 0, len(r.keys))
+	for _, k := range r.keys {
+		if !r.expired(k, now) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// JWKS 返回所有非对称密钥的公钥，HMAC 密钥不会被公开
+func (r *KeyRing) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, k := range r.Keys() {
+		pub := k.Public()
+		if pub == nil {
+			continue
+		}
+		jwk, err := NewJWK(k.Kid, k.Method.Alg(), pub)
+		if err != nil {
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// KeyFunc 根据 token 的 kid 选择验证密钥，算法必须与密钥一致
+func (r *KeyRing) KeyFunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, err := r.Key(kid)
+	if err != nil {
+		return nil, err
+	}
+	if key.Method.Alg() != token.Method.Alg() {
+		return nil, fmt.Errorf("jwt: key %q does not support %s", kid, token.Method.Alg())
+	}
+	return key.VerifyKey(), nil
+}
+
+// Run 按照轮换间隔定期调用 Rotate，没有当前签名密钥时立即轮换，
+// 可以作为 background.TaskFunc 使用。轮换失败时返回错误
+func (r *KeyRing) Run(ctx context.Context) error {
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+		wait := r.untilRotation()
+		if wait <= 0 {
+			if _, err := r.Rotate(); err != nil {
+				return fmt.Errorf("rotate signing key: %w", err)
+			}
+			continue
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+// untilRotation 返回距离下一次轮换的时间
+func (r *KeyRing) untilRotation() time.Duration {
+	current, err := r.Current()
+	if err != nil {
+		return 0
+	}
+	return time.Until(current.CreatedAt.Add(r.o.interval))
+}
+
+func (r *KeyRing) setCurrentLocked(kid string) {
+	now := time.Now()
+	for i := range r.keys {
+		if r.keys[i].Kid == r.current && r.current != kid && !r.keys[i].Retired() {
+			r.keys[i].RetiredAt = now
+		}
+	}
+	r.current = kid
+}
+
+// expired 判断密钥是否已经超过保留期。
+// 密钥只在 Add、Rotate 和 Retire 时被清理，查询时同样需要过滤
+func (r *KeyRing) expired(k SigningKey, now time.Time) bool {
+	return k.Retired() && now.Sub(k.RetiredAt) >= r.o.retention
+}
+
+func (r *KeyRing) pruneLocked() {
+	now := time.Now()
+	r.keys = slices.DeleteFunc(r.keys, func(k SigningKey) bool {
+		return r.expired(k, now)
+	})
+}
+
+func (r *KeyRing) indexLocked(kid string) int {
+	if kid == "" {
+		return -1
+	}
+	return slices.IndexFunc(r.keys, func(k SigningKey) bool {
+		return k.Kid == kid
+	})
+}
@@ -0,0 +1,207 @@
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/transport/http"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateSigningKey(t *testing.T) {
+	for _, method := range []string{"HS256", "RS256", "PS384", "ES256", "ES512", "EdDSA"} {
+		t.Run(method, func(t *testing.T) {
+			key, err := NewSigningKey(method)
+			require.NoError(t, err)
+			require.NotEmpty(t, key.Kid)
+
+			token := jwt.NewWithClaims(key.Method, jwt.RegisteredClaims{Subject: "user"})
+			s, err := token.SignedString(key.Key)
+			require.NoError(t, err)
+
+			_, err = jwt.Parse(s, func(*jwt.Token) (any, error) {
+				return key.VerifyKey(), nil
+			})
+			require.NoError(t, err)
+		})
+	}
+
+	_, _, _, err := GenerateSigningKey("none")
+	require.Error(t, err)
+}
+
+func TestKeyRingRotate(t *testing.T) {
+	r := NewKeyRing(WithKeyRingMethod("ES256"), WithKeyRetention(time.Hour))
+
+	_, err := r.Current()
+	require.ErrorIs(t, err, ErrNoSigningKey)
+
+	first, err := r.Rotate()
+	require.NoError(t, err)
+	second, err := r.Rotate()
+	require.NoError(t, err)
+
+	current, err := r.Current()
+	require.NoError(t, err)
+	require.Equal(t, second.Kid, current.Kid)
+
+	old, err := r.Key(first.Kid)
+	require.NoError(t, err)
+	require.True(t, old.Retired())
+	require.Len(t, r.JWKS().Keys, 2)
+
+	// 超过保留期的密钥在下一次轮换时被清理
+	r.o.retention = 0
+	_, err = r.Rotate()
+	require.NoError(t, err)
+	_, err = r.Key(first.Kid)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+	require.Len(t, r.Keys(), 1)
+}
+
+func TestKeyRingAdd(t *testing.T) {
+	r := NewKeyRing()
+	hmac, err := NewSigningKey("HS256")
+	require.NoError(t, err)
+	ec, err := NewSigningKey("ES256")
+	require.NoError(t, err)
+
+	require.NoError(t, r.Add(hmac, true))
+	require.Error(t, r.Add(hmac, false))
+	require.NoError(t, r.Add(ec, true))
+
+	// HMAC 密钥不会被公开
+	set := r.JWKS()
+	require.Len(t, set.Keys, 1)
+	require.Equal(t, ec.Kid, set.Keys[0].Kid)
+
+	require.NoError(t, r.Retire(ec.Kid))
+	_, err = r.Current()
+	require.ErrorIs(t, err, ErrNoSigningKey)
+}
+
+func TestKeyRingKeyFunc(t *testing.T) {
+	r := NewKeyRing(WithKeyRingMethod("EdDSA"))
+	key, err := r.Rotate()
+	require.NoError(t, err)
+
+	token := jwt.NewWithClaims(key.Method, jwt.RegisteredClaims{})
+	token.Header["kid"] = key.Kid
+	s, err := token.SignedString(key.Key)
+	require.NoError(t, err)
+
+	_, err = jwt.Parse(s, r.KeyFunc)
+	require.NoError(t, err)
+
+	token.Header["kid"] = "unknown"
+	s, err = token.SignedString(key.Key)
+	require.NoError(t, err)
+	_, err = jwt.Parse(s, r.KeyFunc)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestKeyRingRun(t *testing.T) {
+	r := NewKeyRing(WithKeyRingMethod("ES256"), WithRotationInterval(50*time.Millisecond), WithKeyRetention(time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 180*time.Millisecond)
+	defer cancel()
+	require.NoError(t, r.Run(ctx))
+
+	// 启动时轮换一次，之后每 50ms 轮换一次
+	require.GreaterOrEqual(t, len(r.Keys()), 3)
+	_, err := r.Current()
+	require.NoError(t, err)
+}
+
+func TestKeyRingRunCancel(t *testing.T) {
+	// 不是正数的间隔和保留期使用默认值，Run 不会不停地轮换
+	r := NewKeyRing(WithKeyRingMethod("ES256"), WithRotationInterval(0), WithKeyRetention(-time.Second))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- r.Run(ctx)
+	}()
+	require.Eventually(t, func() bool {
+		_, err := r.Current()
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+	require.Len(t, r.Keys(), 1)
+
+	// 被轮换下来的密钥在默认的保留期内仍然可用
+	_, err := r.Rotate()
+	require.NoError(t, err)
+	require.Len(t, r.Keys(), 2)
+
+	// ctx 已经结束时不再轮换
+	require.NoError(t, r.Run(ctx))
+	require.Len(t, r.Keys(), 2)
+}
+
+func TestJWKSHandler(t *testing.T) {
+	r := NewKeyRing()
+	key, err := r.Rotate()
+	require.NoError(t, err)
+
+	srv := http.NewServer()
+	RegisterJWKSHandler(srv, r)
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(nethttp.MethodGet, JWKSPath, nil))
+	require.Equal(t, nethttp.StatusOK, rec.Code)
+
+	var set JWKSet
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &set))
+	require.Len(t, set.Keys, 1)
+	require.Equal(t, key.Kid, set.Keys[0].Kid)
+	require.Equal(t, "RS256", set.Keys[0].Alg)
+
+	pub, err := set.Keys[0].PublicKey()
+	require.NoError(t, err)
+	require.Equal(t, key.Public(), pub)
+}
+
+func TestKeyRingRetentionWithoutRotate(t *testing.T) {
+	r := NewKeyRing(WithKeyRetention(50 * time.Millisecond))
+	old, err := NewSigningKey("ES256")
+	require.NoError(t, err)
+	key, err := NewSigningKey("ES256")
+	require.NoError(t, err)
+	require.NoError(t, r.Add(old, true))
+	require.NoError(t, r.Add(key, true))
+
+	token := jwt.NewWithClaims(old.Method, jwt.RegisteredClaims{})
+	token.Header["kid"] = old.Kid
+	s, err := token.SignedString(old.Key)
+	require.NoError(t, err)
+
+	_, err = jwt.Parse(s, r.KeyFunc)
+	require.NoError(t, err)
+	require.Len(t, r.JWKS().Keys, 2)
+
+	// 超过保留期后即使没有调用 Rotate 也不再被使用和公开
+	time.Sleep(60 * time.Millisecond)
+	_, err = jwt.Parse(s, r.KeyFunc)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+	require.Len(t, r.Keys(), 1)
+	require.Len(t, r.JWKS().Keys, 1)
+
+	// Retire 时清理
+	require.NoError(t, r.Retire(key.Kid))
+	r.mu.RLock()
+	require.Len(t, r.keys, 1)
+	r.mu.RUnlock()
+}
@@ -0,0 +1,168 @@
+package jwt
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrTokenRevoked token 已经被作废
+var ErrTokenRevoked = errors.New("jwt: token has been revoked")
+
+// RevocationStore 保存被作废的 token。
+// 记录可以在 expiresAt 之后被清理，expiresAt 通常为 token 的过期时间
+type RevocationStore interface {
+	// RevokeToken 作废 jti 对应的 token
+	RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+	// RevokeTokenOnce 原子地作废 jti 对应的 token，token 已经被作废时返回 false，
+	// 并发调用时只有一个调用者返回 true
+	RevokeTokenOnce(ctx context.Context, jti string, expiresAt time.Time) (bool, error)
+	// RevokeSubject 作废 subject 在 cutoff 及之前签发的所有 token，
+	// 由于 iat 的精度为秒，与 cutoff 在同一秒内签发的 token 也会被作废
+	RevokeSubject(ctx context.Context, subject string, cutoff, expiresAt time.Time) error
+	// IsRevoked 判断 token 是否被作废，jti 或 subject 为空时不检查对应的记录，
+	// issuedAt 为零值时视为早于任何 cutoff
+	IsRevoked(ctx context.Context, jti, subject string, issuedAt time.Time) (bool, error)
+}
+
+type revocationEntry struct {
+	key       string
+	cutoff    time.Time
+	expiresAt time.Time
+}
+
+// MemoryRevocationStore 基于 LRU 的内存 RevocationStore，所有方法都是线程安全的。
+// 超过容量时最久未被访问的记录会被淘汰，被淘汰的 token 会重新生效，
+// 需要可靠作废时使用持久化的实现
+type MemoryRevocationStore struct {
+	size int
+
+	mu      sync.Mutex
+	ll      *list.List
+	entries map[string]*list.Element
+}
+
+var _ RevocationStore = (*MemoryRevocationStore)(nil)
+
+// NewMemoryRevocationStore 创建最多保存 size 条记录的 MemoryRevocationStore
+func NewMemoryRevocationStore(size int) *MemoryRevocationStore {
+	return &MemoryRevocationStore{
+		size:    max(size, 1),
+		ll:      list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// RevokeToken implements RevocationStore.
+func (s *MemoryRevocationStore) RevokeToken(_ context.Context, jti string, expiresAt time.Time) error {
+	s.put("jti:"+jti, time.Time{}, expiresAt)
+	return nil
+}
+
+// RevokeTokenOnce implements RevocationStore.
+func (s *MemoryRevocationStore) RevokeTokenOnce(_ context.Context, jti string, expiresAt time.Time) (bool, error) {
+	return s.putIfAbsent("jti:"+jti, expiresAt), nil
+}
+
+// RevokeSubject implements RevocationStore.
+func (s *MemoryRevocationStore) RevokeSubject(_ context.Context, subject string, cutoff, expiresAt time.Time) error {
+	s.put("sub:"+subject, cutoff, expiresAt)
+	return nil
+}
+
+// IsRevoked implements RevocationStore.
+func (s *MemoryRevocationStore) IsRevoked(_ context.Context, jti, subject string, issuedAt time.Time) (bool, error) {
+	if jti != "" {
+		if _, ok := s.get("jti:" + jti); ok {
+			return true, nil
+		}
+	}
+	if subject != "" {
+		if e, ok := s.get("sub:" + subject); ok && revokedBefore(issuedAt, e.cutoff) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Len 返回当前保存的记录数量
+func (s *MemoryRevocationStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ll.Len()
+}
+
+func (s *MemoryRevocationStore) put(key string, cutoff, expiresAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.entries[key]; ok {
+		e := el.Value.(*revocationEntry)
+		if cutoff.After(e.cutoff) {
+			e.cutoff = cutoff
+		}
+		if expiresAt.After(e.expiresAt) {
+			e.expiresAt = expiresAt
+		}
+		s.ll.MoveToFront(el)
+		return
+	}
+
+	s.pushLocked(&revocationEntry{key: key, cutoff: cutoff, expiresAt: expiresAt})
+}
+
+func (s *MemoryRevocationStore) pushLocked(e *revocationEntry) {
+	s.entries[e.key] = s.ll.PushFront(e)
+	for s.ll.Len() > s.size {
+		s.removeLocked(s.ll.Back())
+	}
+}
+
+// putIfAbsent 在 key 不存在或者已经过期时写入记录并返回 true
+func (s *MemoryRevocationStore) putIfAbsent(key string, expiresAt time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.entries[key]; ok {
+		if !s.expiredLocked(el) {
+			s.ll.MoveToFront(el)
+			return false
+		}
+		s.removeLocked(el)
+	}
+	s.pushLocked(&revocationEntry{key: key, expiresAt: expiresAt})
+	return true
+}
+
+func (s *MemoryRevocationStore) get(key string) (*revocationEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if s.expiredLocked(el) {
+		s.removeLocked(el)
+		return nil, false
+	}
+	s.ll.MoveToFront(el)
+	return el.Value.(*revocationEntry), true
+}
+
+func (s *MemoryRevocationStore) expiredLocked(el *list.Element) bool {
+	e := el.Value.(*revocationEntry)
+	return !e.expiresAt.IsZero() && time.Now().After(e.expiresAt)
+}
+
+func (s *MemoryRevocationStore) removeLocked(el *list.Element) {
+	s.ll.Remove(el)
+	delete(s.entries, el.Value.(*revocationEntry).key)
+}
+
+// revokedBefore 判断在 issuedAt 签发的 token 是否被 cutoff 作废
+func revokedBefore(issuedAt, cutoff time.Time) bool {
+	return !issuedAt.After(cutoff)
+}
@@ -0,0 +1,115 @@
+package jwt
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryRevocationStore(t *testing.T) {
+	store := NewMemoryRevocationStore(2)
+	ctx := context.Background()
+	now := time.Now()
+
+	require.NoError(t, store.RevokeToken(ctx, "a", now.Add(time.Hour)))
+	require.NoError(t, store.RevokeSubject(ctx, "user", now, now.Add(time.Hour)))
+
+	revoked, err := store.IsRevoked(ctx, "a", "", time.Time{})
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	// cutoff 及之前签发的 token 被作废
+	revoked, err = store.IsRevoked(ctx, "b", "user", now.Truncate(time.Second))
+	require.NoError(t, err)
+	require.True(t, revoked)
+	revoked, err = store.IsRevoked(ctx, "b", "user", now.Add(time.Second))
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	// 超过容量时淘汰最久未被访问的记录
+	require.NoError(t, store.RevokeToken(ctx, "c", now.Add(time.Hour)))
+	require.Equal(t, 2, store.Len())
+	revoked, err = store.IsRevoked(ctx, "a", "", now)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	// 过期的记录不再生效
+	require.NoError(t, store.RevokeToken(ctx, "expired", now.Add(-time.Second)))
+	revoked, err = store.IsRevoked(ctx, "expired", "", now)
+	require.NoError(t, err)
+	require.False(t, revoked)
+}
+
+func TestMemoryRevokeTokenOnce(t *testing.T) {
+	store := NewMemoryRevocationStore(10)
+	ctx := context.Background()
+	now := time.Now()
+
+	won, err := store.RevokeTokenOnce(ctx, "a", now.Add(time.Hour))
+	require.NoError(t, err)
+	require.True(t, won)
+	won, err = store.RevokeTokenOnce(ctx, "a", now.Add(time.Hour))
+	require.NoError(t, err)
+	require.False(t, won)
+
+	// 过期的记录视为不存在
+	require.NoError(t, store.RevokeToken(ctx, "expired", now.Add(-time.Second)))
+	won, err = store.RevokeTokenOnce(ctx, "expired", now.Add(time.Hour))
+	require.NoError(t, err)
+	require.True(t, won)
+}
+
+func TestRefreshRevocation(t *testing.T) {
+	store := NewMemoryRevocationStore(100)
+	iss, _ := newTestIssuer(t, WithRevocationStore(store))
+	ctx := context.Background()
+
+	pair, err := iss.IssuePair("u1", nil)
+	require.NoError(t, err)
+
+	_, err = iss.Refresh(ctx, pair.Refresh.Token, nil)
+	require.NoError(t, err)
+
+	// 使用过的 refresh token 被作废
+	_, err = iss.Refresh(ctx, pair.Refresh.Token, nil)
+	require.ErrorIs(t, err, ErrInvalidRefreshToken)
+	require.ErrorIs(t, err, ErrTokenRevoked)
+
+	// 作废 subject 后之前签发的 refresh token 都不能使用
+	pair, err = iss.IssuePair("u2", nil)
+	require.NoError(t, err)
+	require.NoError(t, store.RevokeSubject(ctx, "u2", time.Now(), time.Now().Add(time.Hour)))
+	_, err = iss.Refresh(ctx, pair.Refresh.Token, nil)
+	require.ErrorIs(t, err, ErrTokenRevoked)
+}
+
+func TestConcurrentRefresh(t *testing.T) {
+	iss, _ := newTestIssuer(t, WithRevocationStore(NewMemoryRevocationStore(100)))
+	pair, err := iss.IssuePair("u1", nil)
+	require.NoError(t, err)
+
+	// 同一个 refresh token 并发刷新时只有一个调用者成功
+	var wg sync.WaitGroup
+	errs := make([]error, 16)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = iss.Refresh(context.Background(), pair.Refresh.Token, nil)
+		}()
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		require.ErrorIs(t, err, ErrTokenRevoked)
+	}
+	require.Equal(t, 1, succeeded)
+}
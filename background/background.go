@@ -12,29 +12,119 @@ import (
 
 var _ transport.Server = (*Background)(nil)
 
-// 非线程安全的后台任务管理器
-// 多次调用 Launch/Close 行为是未定义的
 type BackgroundFunc func(ctx context.Context)
 
+// TaskFunc 可以返回错误的后台任务，
+// 返回的错误会按照重启策略处理，关键任务永久失败时会导致 Background 失败
+type TaskFunc func(ctx context.Context) error
+
+type task struct {
+	name string
+	f    TaskFunc
+	opts taskOptions
+
+	mu     sync.Mutex
+	status TaskStatus
+	// 启动后用于取消任务的 context
+	cancel    context.CancelFunc
+	cancelled bool
+	// 任务永久退出后 close
+	done     chan struct{}
+	doneOnce sync.Once
+	err      error
+}
+
+// newTask 创建任务，name 为未通过 WithName 指定名称时使用的默认名称
+func newTask(f TaskFunc, name string, opts []TaskOption) *task {
+	o := defaultTaskOptions()
+	o.name = name
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &task{
+		name: o.name,
+		f:    f,
+		opts: o,
+		done: make(chan struct{}),
+		status: TaskStatus{
+			Name:  o.name,
+			State: TaskPending,
+		},
+	}
+}
+
+// Background 后台任务管理器，所有方法都是线程安全的，
+// 可以在 Launch 之后继续添加任务，多次调用 Launch/Close 是安全的
 type Background struct {
-	tasks []BackgroundFunc
+	mu    sync.Mutex
+	tasks []*task
 	wg    sync.WaitGroup
 	log   *log.Helper
-	// 会在退出时 close 这个 channel
-	closed chan struct{}
+	// Launch 之后才会被设置，新添加的任务会在这个 context 下立即启动
+	ctx      context.Context
+	launched bool
+	// 会在开始关闭时 close 这个 channel，之后添加的任务会被忽略
+	closed    chan struct{}
+	closeOnce sync.Once
+	clock     Clock
+	// 关闭分组，按照 group 从小到大依次关闭
+	groups       map[int]*shutdownGroup
+	drainTimeout time.Duration
+	groupTimeout time.Duration
+
+	// 关键任务失败时 close 这个 channel
+	fatal     chan struct{}
+	fatalErr  error
+	fatalOnce sync.Once
+	onFatal   func(err error)
 }
 
-func New(logger log.Logger) *Background {
-	return &Background{
-		log:    log.NewHelper(log.With(logger, "module", "background")),
-		closed: make(chan struct{}),
+func New(logger log.Logger, opts ...Option) *Background {
+	bg := &Background{
+		log:          log.NewHelper(log.With(logger, "module", "background")),
+		closed:       make(chan struct{}),
+		clock:        realClock{},
+		groups:       make(map[int]*shutdownGroup),
+		drainTimeout: DefaultDrainTimeout,
+		fatal:        make(chan struct{}),
 	}
+	for _, opt := range opts {
+		opt(bg)
+	}
+	return bg
 }
 
 // Start implements transport.Server.
+// 如果调用 Start 时已经添加了关键任务，Start 会阻塞直到 Background 被关闭或者关键任务失败，
+// 关键任务失败时返回其错误，从而使 kratos.App 停止。
+// 是否阻塞只在调用时决定，之后添加的关键任务失败时不会影响 Start 的返回值，
+// 只会触发 WithFatalHandler 并记录到 Err 中，需要在运行中添加关键任务时应当使用 WithFatalHandler
 func (bg *Background) Start(ctx context.Context) error {
 	bg.Launch(ctx)
-	return nil
+
+	if !bg.hasCritical() {
+		return nil
+	}
+	select {
+	case <-bg.fatal:
+		return bg.fatalErr
+	case <-bg.closed:
+		return nil
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+func (bg *Background) hasCritical() bool {
+	bg.mu.Lock()
+	defer bg.mu.Unlock()
+
+	for _, t := range bg.tasks {
+		if t.opts.critical {
+			return true
+		}
+	}
+	return false
 }
 
 // Stop implements transport.Server.
@@ -43,8 +133,41 @@ func (bg *Background) Stop(ctx context.Context) error {
 	return bg.Close(ctx)
 }
 
-func (bg *Background) Add(f BackgroundFunc) {
-	bg.tasks = append(bg.tasks, f)
+// Add 添加一个使用默认选项的后台任务
+func (bg *Background) Add(f BackgroundFunc) *Handle {
+	return bg.AddWithOptions(f)
+}
+
+// AddWithOptions 添加一个后台任务，可以通过 opts 指定名称、重启策略和退避参数
+func (bg *Background) AddWithOptions(f BackgroundFunc, opts ...TaskOption) *Handle {
+	task := func(ctx context.Context) error {
+		f(ctx)
+		return nil
+	}
+	return bg.add(newTask(task, funcName(f), opts))
+}
+
+// AddTask 添加一个可以返回错误的后台任务
+func (bg *Background) AddTask(f TaskFunc, opts ...TaskOption) *Handle {
+	return bg.add(newTask(f, funcName(f), opts))
+}
+
+// add 注册任务，如果已经 Launch 则立即启动，如果已经 Close 则忽略
+func (bg *Background) add(t *task) *Handle {
+	bg.mu.Lock()
+	defer bg.mu.Unlock()
+
+	if bg.isClosed() {
+		bg.log.Warnf("task %s: added after close, ignored", t.name)
+		t.finish(nil)
+		return &Handle{bg: bg, t: t}
+	}
+
+	bg.tasks = append(bg.tasks, t)
+	if bg.launched {
+		bg.launch(t)
+	}
+	return &Handle{bg: bg, t: t}
 }
 
 // nopanic 捕获来自 f 的 panic 并返回它和堆栈信息
@@ -60,54 +183,122 @@ func nopanic(ctx context.Context, f BackgroundFunc) (p any, stk string) {
 	return
 }
 
-// forever 永远运行，直到 ctx 被取消，
-// 如果 f 发生了 panic 会打印堆栈信息到 logger
-func (bg *Background) forever(ctx context.Context, f BackgroundFunc) error {
-	for ctx.Err() == nil {
-		p, stk := nopanic(ctx, f)
+// forever 按照任务的重启策略运行 t，直到 ctx 被取消，
+// 即使 ctx 已经被取消 t 也至少会运行一次，以便它观察到退出信号。
+// 如果 t 发生了 panic 会打印堆栈信息到 logger。
+// 任务失败且不再重启时返回 *TaskError
+func (bg *Background) forever(ctx context.Context, t *task) (err error) {
+	var (
+		restarts int
+		attempt  int
+	)
+	for {
+		start := time.Now()
+		t.setState(TaskRunning)
+
+		var runErr error
+		p, stk := nopanic(ctx, func(ctx context.Context) {
+			runErr = t.f(ctx)
+		})
+		t.recordExit(runErr)
 
 		if p != nil {
-			bg.log.Warnw("recovered panic %v\n%s", p, stk)
-			continue
+			t.recordPanic(p, stk)
+			bg.log.Warnf("task %s: recovered panic %v\n%s", t.name, p, stk)
+		} else if runErr != nil {
+			bg.log.Warnf("task %s: exited with error: %v", t.name, runErr)
 		}
 
-		return nil
+		// 任务被取消或者所在的分组正在关闭时，任务的退出不视为失败
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		var failure error
+		if p != nil || runErr != nil {
+			failure = &TaskError{Task: t.name, Err: runErr, Panic: p}
+		}
+
+		if !t.opts.restart.shouldRestart(p != nil, runErr != nil) {
+			return failure
+		}
+
+		if t.opts.maxRestarts > 0 && restarts >= t.opts.maxRestarts {
+			bg.log.Errorf("task %s: reached max restarts (%d), giving up", t.name, t.opts.maxRestarts)
+			return failure
+		}
+
+		// 任务稳定运行了足够长的时间，重新从初始退避开始计算
+		if t.opts.backoff.Max > 0 && time.Since(start) >= t.opts.backoff.Max {
+			attempt = 0
+		}
+		delay := t.opts.backoff.Next(attempt)
+		attempt++
+		restarts++
+		t.recordRestart()
+
+		if delay > 0 {
+			bg.log.Infof("task %s: restarting in %s (restart %d)", t.name, delay, restarts)
+		}
+		if !sleepCtx(ctx, delay) {
+			return nil
+		}
 	}
-	return ctx.Err()
 }
 
-// Launch 非阻塞的启动所有已添加的后台任务
+func (bg *Background) isClosed() bool {
+	select {
+	case <-bg.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Launch 非阻塞的启动所有已添加的后台任务，之后添加的任务会立即启动，
+// 重复调用或者在 Close 之后调用不会产生任何效果
 func (bg *Background) Launch(ctx context.Context) {
-	for _, task := range bg.tasks {
-		bg.wg.Add(1)
-		go func(ctx context.Context, task BackgroundFunc) {
-			defer bg.wg.Done()
-			ctx = NewContextWithClosed(ctx, bg.closed)
-			bg.forever(ctx, task)
-		}(ctx, task)
+	bg.mu.Lock()
+	defer bg.mu.Unlock()
+
+	if bg.launched || bg.isClosed() {
+		return
+	}
+	bg.launched = true
+	bg.ctx = ctx
+
+	for _, t := range bg.tasks {
+		bg.launch(t)
 	}
 }
 
-// Close 等待所有后台任务退出并返回
-func (bg *Background) Close(ctx context.Context) error {
-	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
-	defer cancel()
+// launch 在独立的 goroutine 中运行 t，调用者需要持有 bg.mu
+func (bg *Background) launch(t *task) {
+	ctx, cancel := context.WithCancel(bg.ctx)
+	t.mu.Lock()
+	t.cancel = cancel
+	t.mu.Unlock()
 
-	// 通知所有任务退出
-	close(bg.closed)
+	closed := bg.group(t.opts.group).closed
 
-	done := make(chan struct{})
+	bg.wg.Add(1)
 	go func() {
-		bg.wg.Wait()
-		close(done)
-	}()
+		defer bg.wg.Done()
+		defer cancel()
 
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	case <-done:
-		return nil
-	}
+		ctx = NewContextWithClosed(ctx, closed)
+		err := bg.forever(ctx, t)
+		if err != nil && t.opts.critical {
+			bg.fail(err)
+		}
+
+		bg.mu.Lock()
+		defer bg.mu.Unlock()
+		t.finish(err)
+		if t.isCancelled() {
+			bg.remove(t)
+		}
+	}()
 }
 
 func (bg *Background) SetLogger(logger log.Logger) {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/stretchr/testify/require"
@@ -41,3 +42,90 @@ func TestQuitNotice(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, int64(workers), counter)
 }
+
+func TestRestartOnPanic(t *testing.T) {
+	bg := New(log.DefaultLogger)
+
+	var runs int64
+	done := make(chan struct{})
+	bg.AddWithOptions(func(ctx context.Context) {
+		if atomic.AddInt64(&runs, 1) < 3 {
+			panic("boom")
+		}
+		close(done)
+	}, WithBackoff(Backoff{Initial: time.Millisecond, Max: 10 * time.Millisecond, Multiplier: 2}))
+
+	ctx := context.Background()
+	bg.Start(ctx)
+	<-done
+
+	require.NoError(t, bg.Close(ctx))
+	require.Equal(t, int64(3), atomic.LoadInt64(&runs))
+}
+
+func TestRestartNever(t *testing.T) {
+	bg := New(log.DefaultLogger)
+
+	var runs int64
+	bg.AddWithOptions(func(ctx context.Context) {
+		atomic.AddInt64(&runs, 1)
+		panic("boom")
+	}, WithRestartPolicy(RestartNever))
+
+	ctx := context.Background()
+	bg.Start(ctx)
+	time.Sleep(20 * time.Millisecond)
+
+	require.NoError(t, bg.Close(ctx))
+	require.Equal(t, int64(1), atomic.LoadInt64(&runs))
+}
+
+func TestRestartAlwaysMaxRestarts(t *testing.T) {
+	bg := New(log.DefaultLogger)
+
+	var runs int64
+	bg.AddWithOptions(func(ctx context.Context) {
+		atomic.AddInt64(&runs, 1)
+	},
+		WithRestartPolicy(RestartAlways),
+		WithMaxRestarts(4),
+		WithBackoff(Backoff{Initial: time.Millisecond, Max: time.Millisecond}),
+	)
+
+	ctx := context.Background()
+	bg.Start(ctx)
+	bg.wg.Wait()
+
+	require.NoError(t, bg.Close(ctx))
+	require.Equal(t, int64(5), atomic.LoadInt64(&runs))
+}
+
+func TestRestartAlwaysStopsOnClose(t *testing.T) {
+	bg := New(log.DefaultLogger)
+
+	bg.AddWithOptions(func(ctx context.Context) {
+		closed, _ := ClosedFromContext(ctx)
+		<-closed
+	}, WithRestartPolicy(RestartAlways), WithBackoff(Backoff{}))
+
+	ctx := context.Background()
+	bg.Start(ctx)
+
+	require.NoError(t, bg.Close(ctx))
+}
+
+func TestBackoffNext(t *testing.T) {
+	b := Backoff{Initial: 100 * time.Millisecond, Max: time.Second, Multiplier: 2}
+	require.Equal(t, 100*time.Millisecond, b.Next(0))
+	require.Equal(t, 200*time.Millisecond, b.Next(1))
+	require.Equal(t, 800*time.Millisecond, b.Next(3))
+	require.Equal(t, time.Second, b.Next(4))
+	require.Equal(t, time.Second, b.Next(100))
+
+	b.Jitter = 0.5
+	for range 100 {
+		d := b.Next(1)
+		require.GreaterOrEqual(t, d, 100*time.Millisecond)
+		require.LessOrEqual(t, d, 300*time.Millisecond)
+	}
+}
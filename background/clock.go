@@ -0,0 +1,22 @@
+package background
+
+import "time"
+
+// Clock 抽象时间源，调度任务通过它获取当前时间和等待，
+// 测试中可以替换为可控的实现
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+// Now implements Clock.
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// After implements Clock.
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
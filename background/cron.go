@@ -0,0 +1,278 @@
+package background
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var _ Schedule = (*CronSchedule)(nil)
+
+// CronSchedule 标准 cron 表达式描述的调度
+//
+// 支持 5 个字段（分 时 日 月 周）或 6 个字段（秒 分 时 日 月 周），
+// 每个字段支持 *, ?, 列表(,), 范围(-), 步长(/)，月和周支持英文缩写，
+// 另外支持 @yearly, @monthly, @weekly, @daily, @hourly 等宏。
+// 表达式可以使用 "CRON_TZ=Asia/Shanghai " 或 "TZ=..." 前缀指定时区
+type CronSchedule struct {
+	second, minute, hour, dom, month, dow uint64
+	// 日和周都被限制时，两者满足其一即可
+	domStar, dowStar bool
+	loc              *time.Location
+}
+
+type cronBounds struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	secondBounds = cronBounds{0, 59, nil}
+	minuteBounds = cronBounds{0, 59, nil}
+	hourBounds   = cronBounds{0, 23, nil}
+	domBounds    = cronBounds{1, 31, nil}
+	monthBounds  = cronBounds{1, 12, map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 7 同样表示周日
+	dowBounds = cronBounds{0, 7, map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly":  "0 0 0 1 * *",
+	"@weekly":   "0 0 0 * * 0",
+	"@daily":    "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly":   "0 0 * * * *",
+}
+
+// ParseCron 解析 cron 表达式，未指定时区时使用 time.Local
+func ParseCron(expr string) (*CronSchedule, error) {
+	return ParseCronInLocation(expr, time.Local)
+}
+
+// ParseCronInLocation 解析 cron 表达式并在 loc 时区中计算触发时间，
+// 表达式中的 CRON_TZ/TZ 前缀优先于 loc
+func ParseCronInLocation(expr string, loc *time.Location) (*CronSchedule, error) {
+	spec := strings.TrimSpace(expr)
+	if strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=") {
+		tz, rest, _ := strings.Cut(spec, " ")
+		_, name, _ := strings.Cut(tz, "=")
+		l, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("cron %q: load location: %w", expr, err)
+		}
+		loc = l
+		spec = strings.TrimSpace(rest)
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+
+	if macro, ok := cronMacros[strings.ToLower(spec)]; ok {
+		spec = macro
+	}
+
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("cron %q: expected 5 or 6 fields, got %d", expr, len(fields))
+	}
+
+	s := &CronSchedule{loc: loc}
+	targets := []struct {
+		bits   *uint64
+		bounds cronBounds
+		star   *bool
+	}{
+		{&s.second, secondBounds, nil},
+		{&s.minute, minuteBounds, nil},
+		{&s.hour, hourBounds, nil},
+		{&s.dom, domBounds, &s.domStar},
+		{&s.month, monthBounds, nil},
+		{&s.dow, dowBounds, &s.dowStar},
+	}
+	for i, target := range targets {
+		b, star, err := parseCronField(fields[i], target.bounds)
+		if err != nil {
+			return nil, fmt.Errorf("cron %q: %w", expr, err)
+		}
+		*target.bits = b
+		if target.star != nil {
+			*target.star = star
+		}
+	}
+
+	// 周日既可以写作 0 也可以写作 7
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	return s, nil
+}
+
+// MustParseCron 与 ParseCron 相同，但在解析失败时 panic
+func MustParseCron(expr string) *CronSchedule {
+	s, err := ParseCron(expr)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func parseCronField(field string, bounds cronBounds) (result uint64, star bool, err error) {
+	for part := range strings.SplitSeq(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		var lo, hi int
+		switch rangePart {
+		case "*", "?":
+			lo, hi = bounds.min, bounds.max
+			star = !hasStep
+		default:
+			loPart, hiPart, isRange := strings.Cut(rangePart, "-")
+			if lo, err = parseCronValue(loPart, bounds); err != nil {
+				return 0, false, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = parseCronValue(hiPart, bounds); err != nil {
+					return 0, false, err
+				}
+			} else if hasStep {
+				hi = bounds.max
+			}
+		}
+		if lo > hi {
+			return 0, false, fmt.Errorf("invalid range %q", part)
+		}
+
+		step := 1
+		if hasStep {
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step <= 0 {
+				return 0, false, fmt.Errorf("invalid step %q", part)
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			result |= 1 << v
+		}
+	}
+	return result, star && bits.OnesCount64(result) == bounds.max-bounds.min+1, nil
+}
+
+func parseCronValue(s string, bounds cronBounds) (int, error) {
+	if v, ok := bounds.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if v < bounds.min || v > bounds.max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d]", v, bounds.min, bounds.max)
+	}
+	return v, nil
+}
+
+// Next implements Schedule.
+// 返回严格晚于 t 的下一个触发时间，如果五年内都不会触发则返回零值
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	origLoc := t.Location()
+	t = t.In(s.loc)
+
+	// 从下一秒开始查找
+	t = t.Add(time.Second - time.Duration(t.Nanosecond()))
+
+	// 是否已经对 t 做过截断
+	added := false
+	yearLimit := t.Year() + 5
+
+wrap:
+	for t.Year() <= yearLimit {
+		for s.month&(1<<uint(t.Month())) == 0 {
+			if !added {
+				added = true
+				t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, s.loc)
+			}
+			t = t.AddDate(0, 1, 0)
+			if t.Month() == time.January {
+				continue wrap
+			}
+		}
+
+		for !s.dayMatches(t) {
+			if !added {
+				added = true
+				t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.loc)
+			}
+			t = t.AddDate(0, 0, 1)
+			// 夏令时切换可能导致零点不存在
+			if t.Hour() != 0 {
+				if t.Hour() > 12 {
+					t = t.Add(time.Duration(24-t.Hour()) * time.Hour)
+				} else {
+					t = t.Add(-time.Duration(t.Hour()) * time.Hour)
+				}
+			}
+			if t.Day() == 1 {
+				continue wrap
+			}
+		}
+
+		for s.hour&(1<<uint(t.Hour())) == 0 {
+			if !added {
+				added = true
+				t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, s.loc)
+			}
+			t = t.Add(time.Hour)
+			if t.Hour() == 0 {
+				continue wrap
+			}
+		}
+
+		for s.minute&(1<<uint(t.Minute())) == 0 {
+			if !added {
+				added = true
+				t = t.Truncate(time.Minute)
+			}
+			t = t.Add(time.Minute)
+			if t.Minute() == 0 {
+				continue wrap
+			}
+		}
+
+		for s.second&(1<<uint(t.Second())) == 0 {
+			if !added {
+				added = true
+				t = t.Truncate(time.Second)
+			}
+			t = t.Add(time.Second)
+			if t.Second() == 0 {
+				continue wrap
+			}
+		}
+
+		return t.In(origLoc)
+	}
+	return time.Time{}
+}
+
+func (s *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
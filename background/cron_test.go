@@ -0,0 +1,78 @@
+package background
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCronNext(t *testing.T) {
+	cases := []struct {
+		expr string
+		from string
+		want string
+	}{
+		{"* * * * *", "2025-01-01T00:00:00Z", "2025-01-01T00:01:00Z"},
+		{"*/15 * * * *", "2025-01-01T00:07:30Z", "2025-01-01T00:15:00Z"},
+		{"30 * * * * *", "2025-01-01T00:00:30Z", "2025-01-01T00:01:30Z"},
+		{"0 9 * * mon-fri", "2025-01-03T10:00:00Z", "2025-01-06T09:00:00Z"},
+		{"0 0 1 jan *", "2025-03-01T00:00:00Z", "2026-01-01T00:00:00Z"},
+		{"0 0 29 2 *", "2025-01-01T00:00:00Z", "2028-02-29T00:00:00Z"},
+		// 日和周都被限制时满足其一即可
+		{"0 0 13 * 5", "2025-01-01T00:00:00Z", "2025-01-03T00:00:00Z"},
+		{"0 12 * * 7", "2025-01-01T00:00:00Z", "2025-01-05T12:00:00Z"},
+		{"@hourly", "2025-01-01T00:59:59Z", "2025-01-01T01:00:00Z"},
+		{"@daily", "2025-01-01T00:00:00Z", "2025-01-02T00:00:00Z"},
+		{"5-10/5 1,2 * * *", "2025-01-01T01:05:00Z", "2025-01-01T01:10:00Z"},
+	}
+
+	for _, c := range cases {
+		s, err := ParseCronInLocation(c.expr, time.UTC)
+		require.NoError(t, err, c.expr)
+
+		from, _ := time.Parse(time.RFC3339, c.from)
+		want, _ := time.Parse(time.RFC3339, c.want)
+		require.Equal(t, want, s.Next(from), c.expr)
+	}
+}
+
+func TestCronTimeZone(t *testing.T) {
+	s, err := ParseCron("CRON_TZ=Asia/Shanghai 0 8 * * *")
+	require.NoError(t, err)
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.Equal(t, time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC), s.Next(from).UTC())
+
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	s, err = ParseCronInLocation("0 12 * * *", loc)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2025, 1, 1, 17, 0, 0, 0, time.UTC), s.Next(from).UTC())
+}
+
+func TestCronNeverFires(t *testing.T) {
+	s, err := ParseCronInLocation("0 0 30 2 *", time.UTC)
+	require.NoError(t, err)
+	require.True(t, s.Next(time.Now()).IsZero())
+}
+
+func TestCronInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"10-5 * * * *",
+		"*/0 * * * *",
+		"* * * foo *",
+		"CRON_TZ=Nowhere/City * * * * *",
+	} {
+		_, err := ParseCron(expr)
+		require.Error(t, err, expr)
+	}
+}
@@ -0,0 +1,47 @@
+package background
+
+import (
+	"fmt"
+)
+
+// TaskError 描述任务永久失败的原因：返回了错误或者 panic 之后不再重启
+type TaskError struct {
+	Task string
+	// 任务最后一次返回的错误
+	Err error
+	// 任务最后一次 panic 的值
+	Panic any
+}
+
+func (e *TaskError) Error() string {
+	if e.Panic != nil {
+		return fmt.Sprintf("background task %s: panic: %v", e.Task, e.Panic)
+	}
+	return fmt.Sprintf("background task %s: %v", e.Task, e.Err)
+}
+
+func (e *TaskError) Unwrap() error {
+	return e.Err
+}
+
+// fail 记录第一个致命错误并通知 Start 和 fatal handler
+func (bg *Background) fail(err error) {
+	bg.fatalOnce.Do(func() {
+		bg.log.Errorf("fatal task failure: %v", err)
+		bg.fatalErr = err
+		if bg.onFatal != nil {
+			bg.onFatal(err)
+		}
+		close(bg.fatal)
+	})
+}
+
+// Err 返回第一个关键任务失败的错误，如果没有则返回 nil
+func (bg *Background) Err() error {
+	select {
+	case <-bg.fatal:
+		return bg.fatalErr
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,151 @@
+package background
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/require"
+)
+
+var errConsumer = errors.New("consumer is dead")
+
+func TestCriticalTaskFailsStart(t *testing.T) {
+	bg := New(log.DefaultLogger)
+	bg.AddTask(func(ctx context.Context) error {
+		return errConsumer
+	}, WithName("consumer"), WithCritical())
+
+	err := bg.Start(context.Background())
+	require.ErrorIs(t, err, errConsumer)
+
+	var taskErr *TaskError
+	require.ErrorAs(t, err, &taskErr)
+	require.Equal(t, "consumer", taskErr.Task)
+	require.Equal(t, err, bg.Err())
+
+	require.NoError(t, bg.Close(context.Background()))
+	require.Equal(t, errConsumer, bg.Status()[0].LastError)
+}
+
+func TestCriticalTaskAddedAfterStart(t *testing.T) {
+	fatal := make(chan error, 1)
+	bg := New(log.DefaultLogger, WithFatalHandler(func(err error) {
+		fatal <- err
+	}))
+
+	// 没有关键任务时 Start 立即返回，之后添加的关键任务只会触发 WithFatalHandler
+	ctx := context.Background()
+	require.NoError(t, bg.Start(ctx))
+	bg.AddTask(func(ctx context.Context) error {
+		return errConsumer
+	}, WithCritical())
+
+	select {
+	case err := <-fatal:
+		require.ErrorIs(t, err, errConsumer)
+		require.Equal(t, err, bg.Err())
+	case <-time.After(time.Second):
+		t.Fatal("fatal handler was not called")
+	}
+	require.NoError(t, bg.Close(ctx))
+}
+
+func TestCriticalTaskRestartBudget(t *testing.T) {
+	var fatal error
+	bg := New(log.DefaultLogger, WithFatalHandler(func(err error) {
+		fatal = err
+	}))
+
+	var runs int64
+	bg.AddTask(func(ctx context.Context) error {
+		atomic.AddInt64(&runs, 1)
+		panic("boom")
+	},
+		WithCritical(),
+		WithRestartPolicy(RestartOnFailure),
+		WithMaxRestarts(2),
+		WithBackoff(Backoff{Initial: time.Millisecond}),
+	)
+
+	err := bg.Start(context.Background())
+	require.Error(t, err)
+	require.Equal(t, err, fatal)
+	require.Equal(t, int64(3), atomic.LoadInt64(&runs))
+
+	var taskErr *TaskError
+	require.ErrorAs(t, err, &taskErr)
+	require.Equal(t, "boom", taskErr.Panic)
+
+	require.NoError(t, bg.Close(context.Background()))
+}
+
+func TestRestartOnFailure(t *testing.T) {
+	bg := New(log.DefaultLogger)
+
+	var runs int64
+	bg.AddTask(func(ctx context.Context) error {
+		if atomic.AddInt64(&runs, 1) < 3 {
+			return errConsumer
+		}
+		return nil
+	}, WithRestartPolicy(RestartOnFailure), WithBackoff(Backoff{Initial: time.Millisecond}))
+
+	ctx := context.Background()
+	require.NoError(t, bg.Start(ctx))
+	bg.wg.Wait()
+
+	require.NoError(t, bg.Close(ctx))
+	require.NoError(t, bg.Err())
+	require.Equal(t, int64(3), atomic.LoadInt64(&runs))
+}
+
+func TestNonCriticalFailureIgnored(t *testing.T) {
+	bg := New(log.DefaultLogger)
+	bg.AddTask(func(ctx context.Context) error {
+		return errConsumer
+	})
+
+	ctx := context.Background()
+	require.NoError(t, bg.Start(ctx))
+	bg.wg.Wait()
+
+	require.NoError(t, bg.Err())
+	require.NoError(t, bg.Close(ctx))
+}
+
+func TestCriticalTaskStopsOnClose(t *testing.T) {
+	bg := New(log.DefaultLogger)
+	bg.AddTask(func(ctx context.Context) error {
+		closed, _ := ClosedFromContext(ctx)
+		<-closed
+		return errConsumer
+	}, WithCritical())
+
+	ctx := context.Background()
+	started := make(chan error)
+	go func() {
+		started <- bg.Start(ctx)
+	}()
+	require.Eventually(t, func() bool {
+		return bg.Status()[0].State == TaskRunning
+	}, time.Second, time.Millisecond)
+
+	require.NoError(t, bg.Close(ctx))
+	require.NoError(t, <-started)
+	require.NoError(t, bg.Err())
+}
+
+func TestCriticalTaskStopsApp(t *testing.T) {
+	bg := New(log.DefaultLogger)
+	bg.AddTask(func(ctx context.Context) error {
+		return errConsumer
+	}, WithCritical())
+
+	app := kratos.New(kratos.Server(bg))
+	require.ErrorIs(t, app.Run(), errConsumer)
+}
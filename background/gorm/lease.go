@@ -0,0 +1,136 @@
+package gorm
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/unkmonster/go-kit/background"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ background.Locker = (*LeaseLocker)(nil)
+
+// leaseRecord 租约表中的一行
+type leaseRecord struct {
+	Name string `gorm:"primaryKey;size:191"`
+	// 每次获取锁时随机生成，用于确认锁仍然属于当前实例
+	Holder    string    `gorm:"size:64;not null"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
+
+type options struct {
+	table string
+}
+
+type Option func(o *options)
+
+// WithTable 指定租约表的名称，默认为 background_leases
+func WithTable(table string) Option {
+	return func(o *options) {
+		o.table = table
+	}
+}
+
+// LeaseLocker 基于租约表的 Locker，适用于 SQLite 以及其他不支持 GET_LOCK 的数据库
+//
+// 锁的有效期使用应用服务器的时间计算，各个副本之间的时钟偏差需要明显小于 ttl
+type LeaseLocker struct {
+	db    *gorm.DB
+	table string
+}
+
+func NewLeaseLocker(db *gorm.DB, opts ...Option) *LeaseLocker {
+	o := &options{
+		table: "background_leases",
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return &LeaseLocker{
+		db:    db,
+		table: o.table,
+	}
+}
+
+// Migrate 创建或更新租约表
+func (l *LeaseLocker) Migrate(ctx context.Context) error {
+	return l.db.WithContext(ctx).Table(l.table).AutoMigrate(&leaseRecord{})
+}
+
+// TryAcquire implements background.Locker.
+func (l *LeaseLocker) TryAcquire(ctx context.Context, key string, ttl time.Duration) (background.Lease, error) {
+	holder, err := newHolder()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	expiresAt := now.Add(ttl)
+	db := l.db.WithContext(ctx).Table(l.table)
+
+	// 接管已经过期的租约
+	res := db.Where("name = ? AND expires_at < ?", key, now).Updates(map[string]any{
+		"holder":     holder,
+		"expires_at": expiresAt,
+	})
+	if res.Error != nil {
+		return nil, fmt.Errorf("take over lease: %w", res.Error)
+	}
+
+	if res.RowsAffected == 0 {
+		res = l.db.WithContext(ctx).Table(l.table).
+			Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&leaseRecord{Name: key, Holder: holder, ExpiresAt: expiresAt})
+		if res.Error != nil {
+			return nil, fmt.Errorf("create lease: %w", res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return nil, background.ErrLockHeld
+		}
+	}
+
+	return &tableLease{locker: l, key: key, holder: holder}, nil
+}
+
+type tableLease struct {
+	locker *LeaseLocker
+	key    string
+	holder string
+}
+
+// Renew implements background.Lease.
+func (l *tableLease) Renew(ctx context.Context, ttl time.Duration) error {
+	res := l.locker.db.WithContext(ctx).Table(l.locker.table).
+		Where("name = ? AND holder = ?", l.key, l.holder).
+		Update("expires_at", time.Now().UTC().Add(ttl))
+	if res.Error != nil {
+		return fmt.Errorf("%w: %v", background.ErrLeaseLost, res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return background.ErrLeaseLost
+	}
+	return nil
+}
+
+// Release implements background.Lease.
+func (l *tableLease) Release(ctx context.Context) error {
+	err := l.locker.db.WithContext(ctx).Table(l.locker.table).
+		Where("name = ? AND holder = ?", l.key, l.holder).
+		Delete(&leaseRecord{}).Error
+	if err != nil {
+		return fmt.Errorf("delete lease: %w", err)
+	}
+	return nil
+}
+
+func newHolder() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate holder: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,81 @@
+package gorm
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/unkmonster/go-kit/background"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newLeaseLocker(t *testing.T) *LeaseLocker {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "lease.db")), &gorm.Config{})
+	require.NoError(t, err)
+
+	locker := NewLeaseLocker(db)
+	require.NoError(t, locker.Migrate(context.Background()))
+	return locker
+}
+
+func TestLeaseAcquire(t *testing.T) {
+	locker := newLeaseLocker(t)
+	ctx := context.Background()
+
+	lease, err := locker.TryAcquire(ctx, "job", time.Minute)
+	require.NoError(t, err)
+
+	_, err = locker.TryAcquire(ctx, "job", time.Minute)
+	require.ErrorIs(t, err, background.ErrLockHeld)
+
+	// 其他 key 不受影响
+	other, err := locker.TryAcquire(ctx, "other", time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, other.Release(ctx))
+
+	require.NoError(t, lease.Renew(ctx, time.Minute))
+	require.NoError(t, lease.Release(ctx))
+
+	lease, err = locker.TryAcquire(ctx, "job", time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, lease.Release(ctx))
+}
+
+func TestLeaseExpired(t *testing.T) {
+	locker := newLeaseLocker(t)
+	ctx := context.Background()
+
+	stale, err := locker.TryAcquire(ctx, "job", time.Millisecond)
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+
+	lease, err := locker.TryAcquire(ctx, "job", time.Minute)
+	require.NoError(t, err)
+
+	// 过期的持有者既不能续约也不能释放别人的锁
+	require.ErrorIs(t, stale.Renew(ctx, time.Minute), background.ErrLeaseLost)
+	require.NoError(t, stale.Release(ctx))
+	_, err = locker.TryAcquire(ctx, "job", time.Minute)
+	require.ErrorIs(t, err, background.ErrLockHeld)
+
+	require.NoError(t, lease.Release(ctx))
+}
+
+func TestLeaseLeaderTask(t *testing.T) {
+	locker := newLeaseLocker(t)
+
+	ran := make(chan struct{})
+	task := background.LeaderTask(locker, "job", func(ctx context.Context) error {
+		close(ran)
+		return nil
+	})
+	require.NoError(t, task(context.Background()))
+	<-ran
+
+	lease, err := locker.TryAcquire(context.Background(), "job", time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, lease.Release(context.Background()))
+}
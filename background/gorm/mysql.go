@@ -0,0 +1,88 @@
+package gorm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"github.com/unkmonster/go-kit/background"
+	"gorm.io/gorm"
+)
+
+var _ background.Locker = (*MySQLLocker)(nil)
+
+// MySQLLocker 基于 MySQL GET_LOCK 的 Locker
+//
+// GET_LOCK 获取的锁属于数据库连接，因此每个 Lease 会独占一个连接直到被释放，
+// 连接断开时锁会被 MySQL 自动释放，ttl 参数会被忽略
+type MySQLLocker struct {
+	db *gorm.DB
+}
+
+func NewMySQLLocker(db *gorm.DB) *MySQLLocker {
+	return &MySQLLocker{
+		db: db,
+	}
+}
+
+// TryAcquire implements background.Locker.
+func (l *MySQLLocker) TryAcquire(ctx context.Context, key string, ttl time.Duration) (background.Lease, error) {
+	sqlDB, err := l.db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("get sql.DB: %w", err)
+	}
+
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get connection: %w", err)
+	}
+
+	var acquired sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, 0)", key).Scan(&acquired); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("GET_LOCK: %w", err)
+	}
+	if !acquired.Valid || acquired.Int64 != 1 {
+		conn.Close()
+		return nil, background.ErrLockHeld
+	}
+
+	return &mysqlLease{conn: conn, key: key}, nil
+}
+
+type mysqlLease struct {
+	conn *sql.Conn
+	key  string
+}
+
+// Renew implements background.Lease.
+// 检查锁是否仍然被当前连接持有
+func (l *mysqlLease) Renew(ctx context.Context, ttl time.Duration) error {
+	var held sql.NullInt64
+	err := l.conn.QueryRowContext(ctx, "SELECT IS_USED_LOCK(?) = CONNECTION_ID()", l.key).Scan(&held)
+	if err != nil {
+		return fmt.Errorf("%w: IS_USED_LOCK: %v", background.ErrLeaseLost, err)
+	}
+	if !held.Valid || held.Int64 != 1 {
+		return background.ErrLeaseLost
+	}
+	return nil
+}
+
+// Release implements background.Lease.
+// 无论 RELEASE_LOCK 是否成功都会关闭连接。RELEASE_LOCK 失败时连接会被丢弃而不是放回连接池，
+// 会话结束后 MySQL 会释放其持有的锁，避免锁一直被池中的连接持有
+func (l *mysqlLease) Release(ctx context.Context) error {
+	defer l.conn.Close()
+
+	if _, err := l.conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", l.key); err != nil {
+		// 返回 driver.ErrBadConn 使 database/sql 关闭底层连接
+		_ = l.conn.Raw(func(any) error {
+			return driver.ErrBadConn
+		})
+		return fmt.Errorf("RELEASE_LOCK: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package gorm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"github.com/unkmonster/go-kit/background"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func newMySQLLocker(t *testing.T) (*MySQLLocker, sqlmock.Sqlmock) {
+	sqldb, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqldb.Close() })
+
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sqldb,
+		SkipInitializeWithVersion: true,
+	}))
+	require.NoError(t, err)
+	return NewMySQLLocker(db), mock
+}
+
+func TestMySQLAcquire(t *testing.T) {
+	locker, mock := newMySQLLocker(t)
+	ctx := context.Background()
+
+	mock.ExpectQuery("SELECT GET_LOCK").WithArgs("job").
+		WillReturnRows(sqlmock.NewRows([]string{"GET_LOCK"}).AddRow(1))
+	mock.ExpectQuery("SELECT IS_USED_LOCK").WithArgs("job").
+		WillReturnRows(sqlmock.NewRows([]string{"held"}).AddRow(1))
+	mock.ExpectQuery("SELECT IS_USED_LOCK").WithArgs("job").
+		WillReturnRows(sqlmock.NewRows([]string{"held"}).AddRow(0))
+	mock.ExpectExec("SELECT RELEASE_LOCK").WithArgs("job").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	lease, err := locker.TryAcquire(ctx, "job", time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, lease.Renew(ctx, time.Minute))
+	require.ErrorIs(t, lease.Renew(ctx, time.Minute), background.ErrLeaseLost)
+	require.NoError(t, lease.Release(ctx))
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMySQLLockHeld(t *testing.T) {
+	locker, mock := newMySQLLocker(t)
+
+	mock.ExpectQuery("SELECT GET_LOCK").WithArgs("job").
+		WillReturnRows(sqlmock.NewRows([]string{"GET_LOCK"}).AddRow(0))
+
+	_, err := locker.TryAcquire(context.Background(), "job", time.Minute)
+	require.ErrorIs(t, err, background.ErrLockHeld)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMySQLReleaseFailureDiscardsConn(t *testing.T) {
+	sqldb, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqldb.Close() })
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sqldb,
+		SkipInitializeWithVersion: true,
+	}))
+	require.NoError(t, err)
+	locker := NewMySQLLocker(db)
+	ctx := context.Background()
+
+	mock.ExpectQuery("SELECT GET_LOCK").WithArgs("job").
+		WillReturnRows(sqlmock.NewRows([]string{"GET_LOCK"}).AddRow(1))
+	mock.ExpectExec("SELECT RELEASE_LOCK").WithArgs("job").
+		WillReturnError(context.DeadlineExceeded)
+
+	lease, err := locker.TryAcquire(ctx, "job", time.Minute)
+	require.NoError(t, err)
+	require.Error(t, lease.Release(ctx))
+
+	// 持有锁的连接被关闭，而不是放回连接池
+	require.Zero(t, sqldb.Stats().OpenConnections)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,97 @@
+package background
+
+import (
+	"context"
+	"slices"
+)
+
+// Handle 指向一个已添加的任务，可以用来单独取消和等待该任务，
+// 所有方法都是线程安全的
+type Handle struct {
+	bg *Background
+	t  *task
+}
+
+// Name 返回任务名称
+func (h *Handle) Name() string {
+	return h.t.name
+}
+
+// Status 返回任务的状态快照
+func (h *Handle) Status() TaskStatus {
+	return h.t.snapshot()
+}
+
+// Cancel 取消任务的 context 并且不再重启它，任务退出后会从 Background 中移除，
+// 尚未启动的任务会被直接移除。Cancel 不会等待任务退出，需要时使用 Wait
+func (h *Handle) Cancel() {
+	h.bg.mu.Lock()
+	defer h.bg.mu.Unlock()
+
+	t := h.t
+	t.mu.Lock()
+	t.cancelled = true
+	cancel := t.cancel
+	t.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+
+	select {
+	case <-t.done:
+		// 已经退出的任务直接移除
+		h.bg.remove(t)
+	default:
+		if cancel == nil {
+			h.bg.remove(t)
+			t.finish(nil)
+		}
+	}
+}
+
+// Done 返回一个在任务永久退出后被 close 的 channel
+func (h *Handle) Done() <-chan struct{} {
+	return h.t.done
+}
+
+// Wait 等待任务永久退出，返回任务失败的原因（*TaskError），正常退出或被取消时返回 nil。
+// 如果 ctx 先被取消则返回 ctx.Err()
+func (h *Handle) Wait(ctx context.Context) error {
+	select {
+	case <-h.t.done:
+		return h.t.exitErr()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// remove 从任务列表中移除 t，调用者需要持有 bg.mu
+func (bg *Background) remove(t *task) {
+	bg.tasks = slices.DeleteFunc(bg.tasks, func(other *task) bool {
+		return other == t
+	})
+}
+
+// finish 标记任务永久退出
+func (t *task) finish(err error) {
+	t.doneOnce.Do(func() {
+		t.mu.Lock()
+		t.err = err
+		t.mu.Unlock()
+		t.setState(TaskStopped)
+		close(t.done)
+	})
+}
+
+func (t *task) exitErr() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
+
+func (t *task) isCancelled() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.cancelled
+}
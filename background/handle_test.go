@@ -0,0 +1,168 @@
+package background
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddAfterLaunch(t *testing.T) {
+	bg := New(log.DefaultLogger)
+
+	ctx := context.Background()
+	bg.Start(ctx)
+
+	ran := make(chan struct{})
+	h := bg.Add(func(ctx context.Context) {
+		_, ok := ClosedFromContext(ctx)
+		require.True(t, ok)
+		close(ran)
+	})
+	<-ran
+
+	require.NoError(t, h.Wait(ctx))
+	require.Equal(t, TaskStopped, h.Status().State)
+	require.NoError(t, bg.Close(ctx))
+}
+
+func TestCancelRunningTask(t *testing.T) {
+	bg := New(log.DefaultLogger)
+
+	ctx := context.Background()
+	bg.Start(ctx)
+
+	h := bg.AddWithOptions(func(ctx context.Context) {
+		<-ctx.Done()
+	}, WithName("tenant-1"), WithRestartPolicy(RestartAlways))
+	keep := bg.AddWithOptions(func(ctx context.Context) {
+		<-ctx.Done()
+	}, WithName("tenant-2"))
+
+	require.Eventually(t, func() bool {
+		return h.Status().State == TaskRunning
+	}, time.Second, time.Millisecond)
+
+	h.Cancel()
+	require.NoError(t, h.Wait(ctx))
+
+	status := bg.Status()
+	require.Len(t, status, 1)
+	require.Equal(t, "tenant-2", status[0].Name)
+	require.Equal(t, TaskRunning, keep.Status().State)
+
+	keep.Cancel()
+	require.NoError(t, keep.Wait(ctx))
+	require.NoError(t, bg.Close(ctx))
+}
+
+func TestCancelBeforeLaunch(t *testing.T) {
+	bg := New(log.DefaultLogger)
+
+	var runs int64
+	h := bg.Add(func(ctx context.Context) {
+		atomic.AddInt64(&runs, 1)
+	})
+	h.Cancel()
+	h.Cancel()
+
+	<-h.Done()
+	require.Empty(t, bg.Status())
+
+	ctx := context.Background()
+	bg.Start(ctx)
+	require.NoError(t, bg.Close(ctx))
+	require.Equal(t, int64(0), atomic.LoadInt64(&runs))
+}
+
+func TestWaitReturnsFailure(t *testing.T) {
+	bg := New(log.DefaultLogger)
+	h := bg.AddTask(func(ctx context.Context) error {
+		return errConsumer
+	}, WithName("consumer"))
+
+	ctx := context.Background()
+	bg.Start(ctx)
+
+	err := h.Wait(ctx)
+	require.ErrorIs(t, err, errConsumer)
+	require.NoError(t, bg.Close(ctx))
+}
+
+func TestWaitTimeout(t *testing.T) {
+	bg := New(log.DefaultLogger)
+	h := bg.Add(func(ctx context.Context) {
+		closed, _ := ClosedFromContext(ctx)
+		<-closed
+	})
+	bg.Start(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, h.Wait(ctx), context.DeadlineExceeded)
+
+	require.NoError(t, bg.Close(context.Background()))
+	<-h.Done()
+}
+
+func TestLaunchCloseIdempotent(t *testing.T) {
+	bg := New(log.DefaultLogger)
+
+	var runs int64
+	bg.Add(func(ctx context.Context) {
+		atomic.AddInt64(&runs, 1)
+	})
+
+	ctx := context.Background()
+	bg.Launch(ctx)
+	bg.Launch(ctx)
+	require.NoError(t, bg.Close(ctx))
+	require.NoError(t, bg.Close(ctx))
+	bg.Launch(ctx)
+	require.Equal(t, int64(1), atomic.LoadInt64(&runs))
+
+	h := bg.Add(func(ctx context.Context) {
+		atomic.AddInt64(&runs, 1)
+	})
+	<-h.Done()
+	require.Equal(t, int64(1), atomic.LoadInt64(&runs))
+}
+
+func TestConcurrentAdd(t *testing.T) {
+	bg := New(log.DefaultLogger)
+
+	ctx := context.Background()
+	bg.Start(ctx)
+
+	var (
+		wg      sync.WaitGroup
+		counter int64
+	)
+	for i := range 32 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h := bg.AddWithOptions(func(ctx context.Context) {
+				atomic.AddInt64(&counter, 1)
+				closed, _ := ClosedFromContext(ctx)
+				select {
+				case <-ctx.Done():
+				case <-closed:
+				}
+			}, WithName(fmt.Sprintf("worker-%d", i)))
+			if i%2 == 0 {
+				h.Cancel()
+			}
+			bg.Status()
+		}()
+	}
+	wg.Wait()
+
+	require.NoError(t, bg.Close(ctx))
+	require.LessOrEqual(t, atomic.LoadInt64(&counter), int64(32))
+}
@@ -0,0 +1,15 @@
+package background
+
+import (
+	nethttp "net/http"
+
+	"github.com/go-kratos/kratos/v2/transport/http"
+)
+
+// StatusHandler 返回以 JSON 格式输出 Status 快照的 Kratos HTTP handler，
+// 例如 srv.Route("/").GET("/debug/background", bg.StatusHandler())
+func (bg *Background) StatusHandler() http.HandlerFunc {
+	return func(ctx http.Context) error {
+		return ctx.JSON(nethttp.StatusOK, bg.Status())
+	}
+}
@@ -0,0 +1,32 @@
+package background
+
+import (
+	"context"
+	"encoding/json"
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport/http"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusHandler(t *testing.T) {
+	bg := New(log.DefaultLogger)
+	bg.AddWithOptions(func(ctx context.Context) {}, WithName("noop"))
+
+	srv := http.NewServer()
+	srv.Route("/").GET("/debug/background", bg.StatusHandler())
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(nethttp.MethodGet, "/debug/background", nil))
+	require.Equal(t, nethttp.StatusOK, rec.Code)
+
+	var body []map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Len(t, body, 1)
+	require.Equal(t, "noop", body[0]["name"])
+	require.Equal(t, string(TaskPending), body[0]["state"])
+	require.NotContains(t, body[0], "started_at")
+}
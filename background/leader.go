@@ -0,0 +1,193 @@
+package background
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+var (
+	// ErrLockHeld 锁已经被其他实例持有
+	ErrLockHeld = errors.New("background: lock is held by another instance")
+	// ErrLeaseLost 续约失败，锁已经不再属于当前实例
+	ErrLeaseLost = errors.New("background: lease lost")
+)
+
+// Locker 分布式锁，用于在多个副本之间选出运行任务的 leader
+type Locker interface {
+	// TryAcquire 尝试获取名为 key 的锁并持有 ttl，锁被其他实例持有时返回 ErrLockHeld
+	TryAcquire(ctx context.Context, key string, ttl time.Duration) (Lease, error)
+}
+
+// Lease 已经获取到的锁
+type Lease interface {
+	// Renew 将锁的有效期延长 ttl，锁已经丢失时返回 ErrLeaseLost
+	Renew(ctx context.Context, ttl time.Duration) error
+	// Release 释放锁以及 lease 占用的资源（例如数据库连接）。
+	// 续约失败后同样会被调用，实现不能释放已经被其他实例获取的锁
+	Release(ctx context.Context) error
+}
+
+type leaderOptions struct {
+	ttl           time.Duration
+	renewInterval time.Duration
+	retryInterval time.Duration
+	logger        log.Logger
+}
+
+// LeaderOption 配置 Leader 和 LeaderTask
+type LeaderOption func(o *leaderOptions)
+
+// WithLeaseTTL 指定锁的有效期，默认为 15 秒，不是正数时使用默认值
+func WithLeaseTTL(ttl time.Duration) LeaderOption {
+	return func(o *leaderOptions) {
+		o.ttl = ttl
+	}
+}
+
+// WithRenewInterval 指定续约间隔，默认为有效期的 1/3，不是正数或者不短于有效期时使用默认值
+func WithRenewInterval(d time.Duration) LeaderOption {
+	return func(o *leaderOptions) {
+		o.renewInterval = d
+	}
+}
+
+// WithRetryInterval 指定未获取到锁时重试的间隔，默认为 5 秒
+func WithRetryInterval(d time.Duration) LeaderOption {
+	return func(o *leaderOptions) {
+		o.retryInterval = d
+	}
+}
+
+// WithLeaderLogger 指定 leader 选举使用的 logger
+func WithLeaderLogger(logger log.Logger) LeaderOption {
+	return func(o *leaderOptions) {
+		o.logger = logger
+	}
+}
+
+// Leader 包装 f，使其只在获取到 key 对应的锁的实例上运行，
+// 详见 LeaderTask
+func Leader(locker Locker, key string, f BackgroundFunc, opts ...LeaderOption) BackgroundFunc {
+	task := LeaderTask(locker, key, func(ctx context.Context) error {
+		f(ctx)
+		return nil
+	}, opts...)
+	return func(ctx context.Context) {
+		task(ctx)
+	}
+}
+
+// LeaderTask 包装 f，使其只在获取到 key 对应的锁的实例上运行。
+// 未获取到锁时会定期重试，获取到锁后在后台定期续约，
+// 续约失败时取消传给 f 的 ctx，f 退出后重新参与竞争。
+// f 在仍是 leader 时返回会释放锁并返回 f 的结果
+func LeaderTask(locker Locker, key string, f TaskFunc, opts ...LeaderOption) TaskFunc {
+	o := &leaderOptions{
+		ttl:           15 * time.Second,
+		retryInterval: 5 * time.Second,
+		logger:        log.DefaultLogger,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.ttl <= 0 {
+		o.ttl = 15 * time.Second
+	}
+	// 续约间隔必须为正数且短于有效期，否则使用有效期的 1/3，有效期过短时至少为 1ms
+	if o.renewInterval <= 0 || o.renewInterval >= o.ttl {
+		o.renewInterval = max(o.ttl/3, time.Millisecond)
+	}
+	helper := log.NewHelper(log.With(o.logger, "module", "background/leader", "key", key))
+
+	return func(ctx context.Context) error {
+		for {
+			lease, err := locker.TryAcquire(ctx, key, o.ttl)
+			if err != nil {
+				if !errors.Is(err, ErrLockHeld) {
+					helper.Warnf("acquire lock: %v", err)
+				}
+				if !sleepCtx(ctx, o.retryInterval) {
+					return nil
+				}
+				continue
+			}
+
+			helper.Infof("became leader")
+			lost, err := runAsLeader(ctx, lease, o, helper, f)
+			if !lost {
+				return err
+			}
+			helper.Warnf("lost leadership")
+			if ctx.Err() != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// runAsLeader 在持有 lease 期间运行 f，返回是否因为失去 leader 而退出
+func runAsLeader(ctx context.Context, lease Lease, o *leaderOptions, helper *log.Helper, f TaskFunc) (lost bool, err error) {
+	leaderCtx, cancel := context.WithCancel(ctx)
+	renewDone := make(chan struct{})
+	var lostLease bool
+
+	go func() {
+		defer close(renewDone)
+
+		ticker := time.NewTicker(o.renewInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-leaderCtx.Done():
+				return
+			case <-ticker.C:
+				if err := lease.Renew(leaderCtx, o.ttl); err != nil {
+					if leaderCtx.Err() != nil {
+						return
+					}
+					helper.Errorf("renew lease: %v", err)
+					lostLease = true
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	defer func() {
+		cancel()
+		<-renewDone
+
+		// 失去锁之后同样需要释放，续约可能只是暂时失败，此时锁仍然被当前实例持有，
+		// 不释放会导致其他实例一直无法获取锁。
+		// ctx 可能已经被取消，使用独立的 context 释放锁
+		releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), o.ttl)
+		defer cancel()
+		if err := lease.Release(releaseCtx); err != nil {
+			helper.Warnf("release lease: %v", err)
+		}
+		lost = lostLease
+	}()
+
+	return false, f(leaderCtx)
+}
+
+// sleepCtx 等待 d，如果期间 ctx 被取消则返回 false
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
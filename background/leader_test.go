@@ -0,0 +1,215 @@
+package background
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/require"
+)
+
+// memLocker 进程内的 Locker，可以模拟锁被抢占
+type memLocker struct {
+	mu     sync.Mutex
+	holder map[string]*memLease
+	// Release 被调用的次数
+	released int64
+}
+
+type memLease struct {
+	locker *memLocker
+	key    string
+}
+
+func newMemLocker() *memLocker {
+	return &memLocker{holder: make(map[string]*memLease)}
+}
+
+func (l *memLocker) TryAcquire(ctx context.Context, key string, ttl time.Duration) (Lease, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.holder[key] != nil {
+		return nil, ErrLockHeld
+	}
+	lease := &memLease{locker: l, key: key}
+	l.holder[key] = lease
+	return lease, nil
+}
+
+// steal 模拟锁过期后被其他实例获取
+func (l *memLocker) steal(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.holder[key] = &memLease{locker: l, key: key}
+}
+
+func (l *memLocker) clear(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.holder, key)
+}
+
+func (l *memLease) Renew(ctx context.Context, ttl time.Duration) error {
+	l.locker.mu.Lock()
+	defer l.locker.mu.Unlock()
+	if l.locker.holder[l.key] != l {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+func (l *memLease) Release(ctx context.Context) error {
+	atomic.AddInt64(&l.locker.released, 1)
+	l.locker.mu.Lock()
+	defer l.locker.mu.Unlock()
+	if l.locker.holder[l.key] == l {
+		delete(l.locker.holder, l.key)
+	}
+	return nil
+}
+
+func TestLeaderSingleReplica(t *testing.T) {
+	locker := newMemLocker()
+
+	var running, maxRunning int64
+	job := func(ctx context.Context) {
+		n := atomic.AddInt64(&running, 1)
+		defer atomic.AddInt64(&running, -1)
+		for {
+			old := atomic.LoadInt64(&maxRunning)
+			if n <= old || atomic.CompareAndSwapInt64(&maxRunning, old, n) {
+				break
+			}
+		}
+		<-ctx.Done()
+	}
+
+	// 模拟三个副本
+	var replicas []*Background
+	for range 3 {
+		bg := New(log.DefaultLogger)
+		bg.Add(Leader(locker, "cleanup", job,
+			WithLeaseTTL(30*time.Millisecond),
+			WithRetryInterval(5*time.Millisecond),
+		))
+		bg.Start(context.Background())
+		replicas = append(replicas, bg)
+	}
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt64(&running) == 1
+	}, time.Second, time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, int64(1), atomic.LoadInt64(&maxRunning))
+
+	for _, bg := range replicas {
+		require.NoError(t, bg.Close(context.Background()))
+	}
+	require.Equal(t, int64(0), atomic.LoadInt64(&running))
+
+	// 所有副本退出后锁被释放
+	lease, err := locker.TryAcquire(context.Background(), "cleanup", time.Second)
+	require.NoError(t, err)
+	require.NoError(t, lease.Release(context.Background()))
+}
+
+func TestLeaderLostCancelsContext(t *testing.T) {
+	locker := newMemLocker()
+
+	var terms int64
+	lost := make(chan struct{}, 1)
+	task := LeaderTask(locker, "cron", func(ctx context.Context) error {
+		if atomic.AddInt64(&terms, 1) == 1 {
+			<-ctx.Done()
+			lost <- struct{}{}
+			return ctx.Err()
+		}
+		return nil
+	},
+		WithLeaseTTL(30*time.Millisecond),
+		WithRenewInterval(5*time.Millisecond),
+		WithRetryInterval(5*time.Millisecond),
+	)
+
+	done := make(chan error)
+	go func() {
+		done <- task(context.Background())
+	}()
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt64(&terms) == 1
+	}, time.Second, time.Millisecond)
+	locker.steal("cron")
+	<-lost
+
+	// 失去锁之后同样释放 lease，但不会释放其他实例持有的锁
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt64(&locker.released) == 1
+	}, time.Second, time.Millisecond)
+	_, err := locker.TryAcquire(context.Background(), "cron", time.Second)
+	require.ErrorIs(t, err, ErrLockHeld)
+
+	// 锁被其他实例持有期间不会运行
+	time.Sleep(20 * time.Millisecond)
+	require.Equal(t, int64(1), atomic.LoadInt64(&terms))
+
+	locker.clear("cron")
+	require.NoError(t, <-done)
+	require.Equal(t, int64(2), atomic.LoadInt64(&terms))
+}
+
+func TestLeaderReleasesOnReturn(t *testing.T) {
+	locker := newMemLocker()
+
+	task := LeaderTask(locker, "once", func(ctx context.Context) error {
+		return errConsumer
+	})
+	require.ErrorIs(t, task(context.Background()), errConsumer)
+
+	lease, err := locker.TryAcquire(context.Background(), "once", time.Second)
+	require.NoError(t, err)
+	require.NoError(t, lease.Release(context.Background()))
+}
+
+func TestLeaderReleasesOnPanic(t *testing.T) {
+	locker := newMemLocker()
+
+	task := LeaderTask(locker, "panic", func(ctx context.Context) error {
+		panic("boom")
+	})
+	p, _ := nopanic(context.Background(), func(ctx context.Context) {
+		task(ctx)
+	})
+	require.Equal(t, "boom", p)
+
+	lease, err := locker.TryAcquire(context.Background(), "panic", time.Second)
+	require.NoError(t, err)
+	require.NoError(t, lease.Release(context.Background()))
+}
+
+func TestLeaderInvalidDurations(t *testing.T) {
+	for _, opts := range [][]LeaderOption{
+		{WithLeaseTTL(0)},
+		{WithLeaseTTL(2 * time.Nanosecond)},
+		{WithLeaseTTL(-time.Second)},
+		{WithLeaseTTL(time.Nanosecond), WithRenewInterval(time.Nanosecond)},
+		{WithLeaseTTL(time.Second), WithRenewInterval(time.Hour)},
+	} {
+		locker := newMemLocker()
+		ran := make(chan struct{})
+		task := LeaderTask(locker, "job", func(ctx context.Context) error {
+			close(ran)
+			return nil
+		}, opts...)
+
+		// 不会因为 time.NewTicker 的参数不是正数而 panic
+		require.NotPanics(t, func() {
+			require.NoError(t, task(context.Background()))
+		})
+		<-ran
+	}
+}
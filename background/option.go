@@ -0,0 +1,123 @@
+package background
+
+import "time"
+
+type taskOptions struct {
+	name    string
+	restart RestartPolicy
+	backoff Backoff
+	// 最大重启次数，0 表示不限制
+	maxRestarts int
+	// 关键任务永久失败时 Background 会失败
+	critical bool
+	// 关闭分组
+	group int
+	// 仅对 AddScheduled 添加的任务有效
+	overlap   OverlapPolicy
+	missedRun MissedRunPolicy
+}
+
+// TaskOption 配置通过 AddWithOptions 添加的任务
+type TaskOption func(o *taskOptions)
+
+func defaultTaskOptions() taskOptions {
+	return taskOptions{
+		restart: RestartOnPanic,
+		backoff: DefaultBackoff,
+	}
+}
+
+// WithName 指定任务名称，用于日志和状态快照，默认使用函数名
+func WithName(name string) TaskOption {
+	return func(o *taskOptions) {
+		o.name = name
+	}
+}
+
+// WithRestartPolicy 指定任务的重启策略，默认为 RestartOnPanic
+func WithRestartPolicy(p RestartPolicy) TaskOption {
+	return func(o *taskOptions) {
+		o.restart = p
+	}
+}
+
+// WithBackoff 指定重启前的退避参数，默认为 DefaultBackoff
+func WithBackoff(b Backoff) TaskOption {
+	return func(o *taskOptions) {
+		o.backoff = b
+	}
+}
+
+// WithMaxRestarts 指定任务最多被重启多少次，超过后任务将不再运行，
+// n <= 0 表示不限制
+func WithMaxRestarts(n int) TaskOption {
+	return func(o *taskOptions) {
+		o.maxRestarts = max(n, 0)
+	}
+}
+
+// WithCritical 将任务标记为关键任务，
+// 关键任务返回错误或 panic 且不再重启时触发 WithFatalHandler，
+// 如果任务在调用 Start 之前添加，Start 还会返回该错误
+func WithCritical() TaskOption {
+	return func(o *taskOptions) {
+		o.critical = true
+	}
+}
+
+// WithShutdownGroup 指定任务的关闭分组，默认为 0。
+// Close 时按照分组从小到大依次通知任务退出，前一个分组全部退出后才会关闭下一个分组，
+// 例如生产者使用 0，消费者使用 1，保证消费者在生产者之后退出
+func WithShutdownGroup(group int) TaskOption {
+	return func(o *taskOptions) {
+		o.group = group
+	}
+}
+
+// WithOverlapPolicy 指定周期任务的重叠策略，默认为 OverlapSkip
+func WithOverlapPolicy(p OverlapPolicy) TaskOption {
+	return func(o *taskOptions) {
+		o.overlap = p
+	}
+}
+
+// WithMissedRunPolicy 指定周期任务错过触发时的处理策略，默认为 MissedRunSkip
+func WithMissedRunPolicy(p MissedRunPolicy) TaskOption {
+	return func(o *taskOptions) {
+		o.missedRun = p
+	}
+}
+
+// Option 配置 Background
+type Option func(bg *Background)
+
+// WithClock 指定调度任务使用的时钟，默认使用系统时钟
+func WithClock(c Clock) Option {
+	return func(bg *Background) {
+		bg.clock = c
+	}
+}
+
+// WithFatalHandler 指定关键任务失败时的回调，只会被调用一次，
+// 例如传入 func(error) { app.Stop() } 使 kratos.App 退出
+func WithFatalHandler(f func(err error)) Option {
+	return func(bg *Background) {
+		bg.onFatal = f
+	}
+}
+
+// WithDrainTimeout 指定 Close 等待所有任务退出的总时长，默认为 DefaultDrainTimeout，
+// d <= 0 表示仅受 Close 传入的 ctx 限制
+func WithDrainTimeout(d time.Duration) Option {
+	return func(bg *Background) {
+		bg.drainTimeout = d
+	}
+}
+
+// WithGroupDrainTimeout 指定 Close 时每个关闭分组最多等待的时长，
+// 超时后会继续关闭下一个分组，d <= 0 表示不单独限制
+func WithGroupDrainTimeout(d time.Duration) Option {
+	return func(bg *Background) {
+		bg.groupTimeout = d
+	}
+}
@@ -0,0 +1,271 @@
+package background
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+var _ transport.Server = (*Pool)(nil)
+
+var (
+	// ErrPoolClosed 向已经停止的 Pool 提交任务，或者任务在 Pool 停止时仍未运行
+	ErrPoolClosed = errors.New("background: pool is closed")
+	// ErrPoolFull TrySubmit 时队列已满
+	ErrPoolFull = errors.New("background: pool queue is full")
+)
+
+// Job 提交到 Pool 的任务，返回值通过 Future 获取
+type Job func(ctx context.Context) (any, error)
+
+// PanicError Job 发生 panic 时 Future 返回的错误
+type PanicError struct {
+	Value any
+	Stack string
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("background: job panic: %v", e.Value)
+}
+
+// Future 表示一个 Job 的执行结果
+type Future struct {
+	done  chan struct{}
+	value any
+	err   error
+}
+
+// Done 返回一个在 Job 完成后被 close 的 channel
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait 等待 Job 完成并返回结果，如果 ctx 先被取消则返回 ctx.Err()
+func (f *Future) Wait(ctx context.Context) (any, error) {
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (f *Future) complete(value any, err error) {
+	f.value = value
+	f.err = err
+	close(f.done)
+}
+
+type poolJob struct {
+	job    Job
+	future *Future
+}
+
+type poolOptions struct {
+	workers   int
+	queueSize int
+}
+
+// PoolOption 配置 Pool
+type PoolOption func(o *poolOptions)
+
+// WithWorkers 指定 worker 数量，默认为 runtime.NumCPU()
+func WithWorkers(n int) PoolOption {
+	return func(o *poolOptions) {
+		o.workers = n
+	}
+}
+
+// WithQueueSize 指定等待队列的长度，队列满时 Submit 会阻塞，TrySubmit 会返回 ErrPoolFull，
+// 默认与 worker 数量相同
+func WithQueueSize(n int) PoolOption {
+	return func(o *poolOptions) {
+		o.queueSize = n
+	}
+}
+
+// Pool 固定数量 worker 和有界队列的任务池，所有方法都是线程安全的。
+// Stop 时不再接受新的任务，已经提交的任务会被执行完毕
+type Pool struct {
+	log     *log.Helper
+	workers int
+	queue   chan *poolJob
+
+	// 保护 queue 的关闭，Submit 持有读锁
+	mu     sync.RWMutex
+	closed bool
+	// 开始停止时 close，唤醒阻塞在 Submit 的调用者
+	closing   chan struct{}
+	closeOnce sync.Once
+
+	startOnce sync.Once
+	started   bool
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+func NewPool(logger log.Logger, opts ...PoolOption) *Pool {
+	o := &poolOptions{
+		workers: runtime.NumCPU(),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	o.workers = max(o.workers, 1)
+	if o.queueSize <= 0 {
+		o.queueSize = o.workers
+	}
+
+	return &Pool{
+		log:     log.NewHelper(log.With(logger, "module", "background/pool")),
+		workers: o.workers,
+		queue:   make(chan *poolJob, o.queueSize),
+		closing: make(chan struct{}),
+	}
+}
+
+// Start implements transport.Server.
+// 非阻塞的启动所有 worker，Job 在由 ctx 派生的 context 中运行
+func (p *Pool) Start(ctx context.Context) error {
+	p.startOnce.Do(func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.closed {
+			return
+		}
+
+		p.started = true
+		p.ctx, p.cancel = context.WithCancel(ctx)
+		for range p.workers {
+			p.wg.Add(1)
+			go p.worker()
+		}
+	})
+	return nil
+}
+
+// Stop implements transport.Server.
+// 停止接受新任务并等待队列中的任务执行完毕，
+// 如果 ctx 先被取消，正在运行的 Job 的 context 会被取消，尚未运行的 Job 返回 ErrPoolClosed
+func (p *Pool) Stop(ctx context.Context) error {
+	p.log.Warnf("[Pool] stopping")
+
+	p.closeOnce.Do(func() {
+		close(p.closing)
+	})
+
+	// 等待所有正在提交的调用者退出后再关闭队列
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	started := p.started
+	p.mu.Unlock()
+
+	if !started {
+		for j := range p.queue {
+			j.future.complete(nil, ErrPoolClosed)
+		}
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		return ctx.Err()
+	}
+}
+
+// Submit 提交 Job，队列已满时阻塞直到有空位、ctx 被取消或者 Pool 停止。
+// ctx 只用于控制提交，Job 本身在 Pool 的 context 中运行
+func (p *Pool) Submit(ctx context.Context, job Job) (*Future, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return nil, ErrPoolClosed
+	}
+
+	j := newPoolJob(job)
+	select {
+	case p.queue <- j:
+		return j.future, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-p.closing:
+		return nil, ErrPoolClosed
+	}
+}
+
+// TrySubmit 提交 Job，队列已满时立即返回 ErrPoolFull
+func (p *Pool) TrySubmit(job Job) (*Future, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return nil, ErrPoolClosed
+	}
+
+	j := newPoolJob(job)
+	select {
+	case p.queue <- j:
+		return j.future, nil
+	default:
+		return nil, ErrPoolFull
+	}
+}
+
+// Pending 返回队列中等待运行的 Job 数量
+func (p *Pool) Pending() int {
+	return len(p.queue)
+}
+
+func newPoolJob(job Job) *poolJob {
+	return &poolJob{
+		job:    job,
+		future: &Future{done: make(chan struct{})},
+	}
+}
+
+func (p *Pool) worker() {
+	defer p.wg.Done()
+
+	for j := range p.queue {
+		// 停止超时后不再运行剩余的 Job
+		if p.ctx.Err() != nil {
+			j.future.complete(nil, ErrPoolClosed)
+			continue
+		}
+		p.run(j)
+	}
+}
+
+// run 运行 j 并恢复其中的 panic
+func (p *Pool) run(j *poolJob) {
+	var (
+		value any
+		err   error
+	)
+	pv, stk := nopanic(p.ctx, func(ctx context.Context) {
+		value, err = j.job(ctx)
+	})
+	if pv != nil {
+		p.log.Warnf("recovered job panic %v\n%s", pv, stk)
+		value, err = nil, &PanicError{Value: pv, Stack: stk}
+	}
+	j.future.complete(value, err)
+}
@@ -0,0 +1,180 @@
+package background
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoolSubmit(t *testing.T) {
+	pool := NewPool(log.DefaultLogger, WithWorkers(4))
+
+	ctx := context.Background()
+	require.NoError(t, pool.Start(ctx))
+
+	var futures []*Future
+	for i := range 16 {
+		f, err := pool.Submit(ctx, func(ctx context.Context) (any, error) {
+			return i * i, nil
+		})
+		require.NoError(t, err)
+		futures = append(futures, f)
+	}
+
+	for i, f := range futures {
+		v, err := f.Wait(ctx)
+		require.NoError(t, err)
+		require.Equal(t, i*i, v)
+	}
+	require.NoError(t, pool.Stop(ctx))
+}
+
+func TestPoolJobErrorAndPanic(t *testing.T) {
+	pool := NewPool(log.DefaultLogger, WithWorkers(1))
+
+	ctx := context.Background()
+	pool.Start(ctx)
+
+	errJob := errors.New("job failed")
+	f1, err := pool.Submit(ctx, func(ctx context.Context) (any, error) {
+		return nil, errJob
+	})
+	require.NoError(t, err)
+	f2, err := pool.Submit(ctx, func(ctx context.Context) (any, error) {
+		panic("boom")
+	})
+	require.NoError(t, err)
+	f3, err := pool.Submit(ctx, func(ctx context.Context) (any, error) {
+		return "still alive", nil
+	})
+	require.NoError(t, err)
+
+	_, err = f1.Wait(ctx)
+	require.ErrorIs(t, err, errJob)
+
+	_, err = f2.Wait(ctx)
+	var panicErr *PanicError
+	require.ErrorAs(t, err, &panicErr)
+	require.Equal(t, "boom", panicErr.Value)
+	require.NotEmpty(t, panicErr.Stack)
+
+	v, err := f3.Wait(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "still alive", v)
+
+	require.NoError(t, pool.Stop(ctx))
+}
+
+func TestPoolBackpressure(t *testing.T) {
+	pool := NewPool(log.DefaultLogger, WithWorkers(1), WithQueueSize(1))
+
+	ctx := context.Background()
+	pool.Start(ctx)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	_, err := pool.Submit(ctx, func(ctx context.Context) (any, error) {
+		close(started)
+		<-release
+		return nil, nil
+	})
+	require.NoError(t, err)
+	<-started
+
+	// worker 忙碌，队列还有一个空位
+	_, err = pool.TrySubmit(func(ctx context.Context) (any, error) { return nil, nil })
+	require.NoError(t, err)
+	_, err = pool.TrySubmit(func(ctx context.Context) (any, error) { return nil, nil })
+	require.ErrorIs(t, err, ErrPoolFull)
+
+	submitCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = pool.Submit(submitCtx, func(ctx context.Context) (any, error) { return nil, nil })
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(release)
+	require.NoError(t, pool.Stop(ctx))
+}
+
+func TestPoolDrainOnStop(t *testing.T) {
+	pool := NewPool(log.DefaultLogger, WithWorkers(2), WithQueueSize(32))
+
+	ctx := context.Background()
+	pool.Start(ctx)
+
+	var counter int64
+	var futures []*Future
+	for range 32 {
+		f, err := pool.TrySubmit(func(ctx context.Context) (any, error) {
+			time.Sleep(time.Millisecond)
+			atomic.AddInt64(&counter, 1)
+			return nil, nil
+		})
+		require.NoError(t, err)
+		futures = append(futures, f)
+	}
+
+	require.NoError(t, pool.Stop(ctx))
+	require.Equal(t, int64(32), atomic.LoadInt64(&counter))
+	for _, f := range futures {
+		<-f.Done()
+	}
+
+	_, err := pool.Submit(ctx, func(ctx context.Context) (any, error) { return nil, nil })
+	require.ErrorIs(t, err, ErrPoolClosed)
+	_, err = pool.TrySubmit(func(ctx context.Context) (any, error) { return nil, nil })
+	require.ErrorIs(t, err, ErrPoolClosed)
+}
+
+func TestPoolStopTimeout(t *testing.T) {
+	pool := NewPool(log.DefaultLogger, WithWorkers(1), WithQueueSize(4))
+
+	ctx := context.Background()
+	pool.Start(ctx)
+
+	running, err := pool.Submit(ctx, func(ctx context.Context) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	require.NoError(t, err)
+	queued, err := pool.Submit(ctx, func(ctx context.Context) (any, error) {
+		return "never", nil
+	})
+	require.NoError(t, err)
+
+	stopCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, pool.Stop(stopCtx), context.DeadlineExceeded)
+
+	_, err = running.Wait(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+	_, err = queued.Wait(ctx)
+	require.ErrorIs(t, err, ErrPoolClosed)
+}
+
+func TestPoolStopUnblocksSubmit(t *testing.T) {
+	pool := NewPool(log.DefaultLogger, WithWorkers(1), WithQueueSize(1))
+
+	ctx := context.Background()
+	queued, err := pool.TrySubmit(func(ctx context.Context) (any, error) { return nil, nil })
+	require.NoError(t, err)
+
+	blocked := make(chan error)
+	go func() {
+		_, err := pool.Submit(ctx, func(ctx context.Context) (any, error) { return nil, nil })
+		blocked <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, pool.Stop(ctx))
+	require.ErrorIs(t, <-blocked, ErrPoolClosed)
+
+	// 从未启动的 Pool 中排队的 Job 不会运行
+	_, err = queued.Wait(ctx)
+	require.ErrorIs(t, err, ErrPoolClosed)
+}
@@ -0,0 +1,94 @@
+package background
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// RestartPolicy 决定任务退出后是否重新启动
+type RestartPolicy int
+
+const (
+	// RestartOnPanic 仅在任务 panic 时重启，这是默认策略
+	RestartOnPanic RestartPolicy = iota
+	// RestartNever 任务退出后不再重启
+	RestartNever
+	// RestartAlways 无论任务是 panic 还是正常返回都会重启，直到 Background 被关闭
+	RestartAlways
+	// RestartOnFailure 在任务 panic 或者返回错误时重启
+	RestartOnFailure
+)
+
+func (p RestartPolicy) String() string {
+	switch p {
+	case RestartOnPanic:
+		return "on-panic"
+	case RestartNever:
+		return "never"
+	case RestartAlways:
+		return "always"
+	case RestartOnFailure:
+		return "on-failure"
+	}
+	return "unknown"
+}
+
+// shouldRestart 根据任务的退出方式判断是否需要重启
+func (p RestartPolicy) shouldRestart(panicked, failed bool) bool {
+	switch p {
+	case RestartAlways:
+		return true
+	case RestartOnPanic:
+		return panicked
+	case RestartOnFailure:
+		return panicked || failed
+	}
+	return false
+}
+
+// Backoff 指数退避参数
+// 第 n 次重启前等待 Initial * Multiplier^n，最大不超过 Max，
+// 然后在 [-Jitter, +Jitter] 比例范围内随机抖动
+type Backoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+	// 0 表示不抖动，取值范围 [0, 1]
+	Jitter float64
+}
+
+// DefaultBackoff 默认的退避参数
+var DefaultBackoff = Backoff{
+	Initial:    100 * time.Millisecond,
+	Max:        30 * time.Second,
+	Multiplier: 2,
+	Jitter:     0.2,
+}
+
+// Next 返回第 attempt 次（从 0 开始）重启前需要等待的时间
+func (b Backoff) Next(attempt int) time.Duration {
+	if b.Initial <= 0 {
+		return 0
+	}
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	delay := float64(b.Initial)
+	for range attempt {
+		delay *= multiplier
+		if b.Max > 0 && delay >= float64(b.Max) {
+			delay = float64(b.Max)
+			break
+		}
+	}
+	if b.Max > 0 && delay > float64(b.Max) {
+		delay = float64(b.Max)
+	}
+
+	if jitter := min(max(b.Jitter, 0), 1); jitter > 0 {
+		delay += delay * jitter * (rand.Float64()*2 - 1)
+	}
+	return time.Duration(delay)
+}
@@ -0,0 +1,164 @@
+package background
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Schedule 描述周期任务的触发时间
+type Schedule interface {
+	// Next 返回晚于 t 的下一个触发时间，返回零值表示不再触发
+	Next(t time.Time) time.Time
+}
+
+// OverlapPolicy 决定上一次运行尚未结束时如何处理新的触发
+type OverlapPolicy int
+
+const (
+	// OverlapSkip 不允许重叠运行，等待上一次运行结束后按照 MissedRunPolicy 处理错过的触发，这是默认策略
+	OverlapSkip OverlapPolicy = iota
+	// OverlapAllow 允许多次运行同时进行
+	OverlapAllow
+)
+
+// MissedRunPolicy 决定如何处理因为运行超时或者时钟跳变而错过的触发
+type MissedRunPolicy int
+
+const (
+	// MissedRunSkip 丢弃所有错过的触发，等待下一个未来的触发时间，这是默认策略
+	MissedRunSkip MissedRunPolicy = iota
+	// MissedRunOnce 无论错过多少次，都立即补跑一次
+	MissedRunOnce
+	// MissedRunAll 依次补跑每一次错过的触发
+	MissedRunAll
+)
+
+type intervalSchedule struct {
+	interval time.Duration
+	// 为 true 时下一次触发时间从上一次运行结束时开始计算
+	fixedDelay bool
+}
+
+// Next implements Schedule.
+func (s *intervalSchedule) Next(t time.Time) time.Time {
+	return t.Add(s.interval)
+}
+
+// Every 以固定频率触发，两次触发的间隔为 d，不受运行时长影响
+func Every(d time.Duration) Schedule {
+	if d <= 0 {
+		panic("background: non-positive interval for Every")
+	}
+	return &intervalSchedule{interval: d}
+}
+
+// FixedDelay 在上一次运行结束 d 之后再次触发
+func FixedDelay(d time.Duration) Schedule {
+	if d <= 0 {
+		panic("background: non-positive delay for FixedDelay")
+	}
+	return &intervalSchedule{interval: d, fixedDelay: true}
+}
+
+// AddScheduled 添加一个按照 s 周期运行的任务，
+// 单次运行中的 panic 会被恢复并记录，不会影响后续的触发
+func (bg *Background) AddScheduled(s Schedule, f BackgroundFunc, opts ...TaskOption) *Handle {
+	t := newTask(nil, funcName(f), opts)
+	t.f = func(ctx context.Context) error {
+		bg.runSchedule(ctx, t, s, f)
+		return nil
+	}
+	return bg.add(t)
+}
+
+// runSchedule 按照 s 运行 f，直到 ctx 被取消
+func (bg *Background) runSchedule(ctx context.Context, t *task, s Schedule, f BackgroundFunc) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	o := t.opts
+	run := func(ctx context.Context) {
+		if p, stk := nopanic(ctx, f); p != nil {
+			t.recordPanic(p, stk)
+			bg.log.Warnf("task %s: recovered panic in scheduled run %v\n%s", t.name, p, stk)
+		}
+	}
+
+	interval, _ := s.(*intervalSchedule)
+	fixedDelay := interval != nil && interval.fixedDelay
+
+	fire := func() {
+		t.setState(TaskRunning)
+		if o.overlap == OverlapAllow && !fixedDelay {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				run(ctx)
+			}()
+			return
+		}
+		run(ctx)
+	}
+
+	next := s.Next(bg.clock.Now())
+	for !next.IsZero() {
+		t.setNextRun(next)
+		if !bg.waitUntil(ctx, next) {
+			return
+		}
+		fire()
+
+		now := bg.clock.Now()
+		if fixedDelay {
+			next = s.Next(now)
+			continue
+		}
+
+		next = s.Next(next)
+		if next.IsZero() || next.After(now) {
+			continue
+		}
+
+		// 错过了至少一次触发
+		switch o.missedRun {
+		case MissedRunAll:
+		case MissedRunOnce:
+			if ctx.Err() != nil {
+				return
+			}
+			fire()
+			next = skipMissed(s, next, bg.clock.Now())
+		default:
+			next = skipMissed(s, next, now)
+		}
+	}
+}
+
+// skipMissed 返回 next 之后第一个晚于 now 的触发时间
+func skipMissed(s Schedule, next, now time.Time) time.Time {
+	if interval, ok := s.(*intervalSchedule); ok {
+		// 保持固定频率的相位
+		n := now.Sub(next)/interval.interval + 1
+		return next.Add(n * interval.interval)
+	}
+	for !next.IsZero() && !next.After(now) {
+		next = s.Next(now)
+	}
+	return next
+}
+
+// waitUntil 等待到 t，如果期间 ctx 被取消则返回 false
+func (bg *Background) waitUntil(ctx context.Context, t time.Time) bool {
+	d := t.Sub(bg.clock.Now())
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+
+	select {
+	case <-bg.clock.After(d):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,288 @@
+package background
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// fakeClock 只有在调用 Advance 时才会前进的时钟
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeWaiter{deadline: c.now.Add(d), ch: ch})
+	return ch
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	waiters := c.waiters[:0]
+	for _, w := range c.waiters {
+		if !w.deadline.After(c.now) {
+			w.ch <- c.now
+			continue
+		}
+		waiters = append(waiters, w)
+	}
+	c.waiters = waiters
+}
+
+// BlockUntil 等待至少 n 个 goroutine 在时钟上等待
+func (c *fakeClock) BlockUntil(n int) {
+	for {
+		c.mu.Lock()
+		count := len(c.waiters)
+		c.mu.Unlock()
+		if count >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestScheduleEvery(t *testing.T) {
+	clock := newFakeClock()
+	bg := New(log.DefaultLogger, WithClock(clock))
+
+	runs := make(chan time.Time, 10)
+	bg.AddScheduled(Every(time.Minute), func(ctx context.Context) {
+		runs <- clock.Now()
+	})
+
+	ctx := context.Background()
+	bg.Start(ctx)
+
+	start := clock.Now()
+	for i := 1; i <= 3; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(time.Minute)
+		require.Equal(t, start.Add(time.Duration(i)*time.Minute), <-runs)
+	}
+
+	require.NoError(t, bg.Close(ctx))
+}
+
+func TestScheduleCron(t *testing.T) {
+	clock := newFakeClock()
+	bg := New(log.DefaultLogger, WithClock(clock))
+
+	var runs int64
+	bg.AddScheduled(MustParseCron("CRON_TZ=UTC 0 * * * *"), func(ctx context.Context) {
+		atomic.AddInt64(&runs, 1)
+	})
+
+	ctx := context.Background()
+	bg.Start(ctx)
+
+	clock.BlockUntil(1)
+	clock.Advance(59 * time.Minute)
+	require.Equal(t, int64(0), atomic.LoadInt64(&runs))
+
+	clock.Advance(time.Minute)
+	clock.BlockUntil(1)
+	require.Equal(t, int64(1), atomic.LoadInt64(&runs))
+
+	require.NoError(t, bg.Close(ctx))
+}
+
+// 第一次运行耗时 3.5 个周期，检查之后的触发时间
+func testMissedRun(t *testing.T, policy MissedRunPolicy) []time.Duration {
+	clock := newFakeClock()
+	bg := New(log.DefaultLogger, WithClock(clock))
+	start := clock.Now()
+
+	var (
+		mu    sync.Mutex
+		calls []time.Duration
+	)
+	bg.AddScheduled(Every(time.Minute), func(ctx context.Context) {
+		mu.Lock()
+		calls = append(calls, clock.Now().Sub(start))
+		first := len(calls) == 1
+		mu.Unlock()
+
+		if first {
+			clock.Advance(3*time.Minute + 30*time.Second)
+		}
+	}, WithMissedRunPolicy(policy))
+
+	ctx := context.Background()
+	bg.Start(ctx)
+
+	for clock.Now().Sub(start) < 6*time.Minute {
+		clock.BlockUntil(1)
+		clock.Advance(30 * time.Second)
+	}
+	clock.BlockUntil(1)
+	require.NoError(t, bg.Close(ctx))
+
+	mu.Lock()
+	defer mu.Unlock()
+	return calls
+}
+
+func TestMissedRunSkip(t *testing.T) {
+	calls := testMissedRun(t, MissedRunSkip)
+	require.Equal(t, []time.Duration{
+		time.Minute,
+		5 * time.Minute,
+		6 * time.Minute,
+	}, calls)
+}
+
+func TestMissedRunOnce(t *testing.T) {
+	calls := testMissedRun(t, MissedRunOnce)
+	require.Equal(t, []time.Duration{
+		time.Minute,
+		4*time.Minute + 30*time.Second,
+		5 * time.Minute,
+		6 * time.Minute,
+	}, calls)
+}
+
+func TestMissedRunAll(t *testing.T) {
+	calls := testMissedRun(t, MissedRunAll)
+	require.Equal(t, []time.Duration{
+		time.Minute,
+		4*time.Minute + 30*time.Second,
+		4*time.Minute + 30*time.Second,
+		4*time.Minute + 30*time.Second,
+		5 * time.Minute,
+		6 * time.Minute,
+	}, calls)
+}
+
+func TestScheduleFixedDelay(t *testing.T) {
+	clock := newFakeClock()
+	bg := New(log.DefaultLogger, WithClock(clock))
+	start := clock.Now()
+
+	runs := make(chan time.Duration, 10)
+	bg.AddScheduled(FixedDelay(time.Minute), func(ctx context.Context) {
+		runs <- clock.Now().Sub(start)
+		// 每次运行耗时 30 秒
+		clock.Advance(30 * time.Second)
+	})
+
+	ctx := context.Background()
+	bg.Start(ctx)
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	require.Equal(t, time.Minute, <-runs)
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	require.Equal(t, 2*time.Minute+30*time.Second, <-runs)
+
+	require.NoError(t, bg.Close(ctx))
+}
+
+func TestScheduledPanicDoesNotStop(t *testing.T) {
+	clock := newFakeClock()
+	bg := New(log.DefaultLogger, WithClock(clock))
+
+	var runs int64
+	bg.AddScheduled(Every(time.Second), func(ctx context.Context) {
+		atomic.AddInt64(&runs, 1)
+		panic("boom")
+	})
+
+	ctx := context.Background()
+	bg.Start(ctx)
+
+	for range 3 {
+		clock.BlockUntil(1)
+		clock.Advance(time.Second)
+	}
+	clock.BlockUntil(1)
+
+	require.NoError(t, bg.Close(ctx))
+	require.Equal(t, int64(3), atomic.LoadInt64(&runs))
+}
+
+func TestScheduleStopsOnClose(t *testing.T) {
+	bg := New(log.DefaultLogger)
+	bg.AddScheduled(Every(time.Hour), func(ctx context.Context) {})
+
+	ctx := context.Background()
+	bg.Start(ctx)
+
+	start := time.Now()
+	require.NoError(t, bg.Close(ctx))
+	require.Less(t, time.Since(start), time.Second)
+}
+
+func TestScheduleOverlap(t *testing.T) {
+	for _, c := range []struct {
+		policy OverlapPolicy
+		want   int64
+	}{
+		{OverlapSkip, 1},
+		{OverlapAllow, 3},
+	} {
+		clock := newFakeClock()
+		bg := New(log.DefaultLogger, WithClock(clock))
+
+		var running int64
+		release := make(chan struct{})
+		bg.AddScheduled(Every(time.Second), func(ctx context.Context) {
+			atomic.AddInt64(&running, 1)
+			<-release
+		}, WithOverlapPolicy(c.policy))
+
+		ctx := context.Background()
+		bg.Start(ctx)
+
+		clock.BlockUntil(1)
+		clock.Advance(time.Second)
+		for range 2 {
+			if c.policy == OverlapAllow {
+				clock.BlockUntil(1)
+			}
+			clock.Advance(time.Second)
+		}
+		require.Eventually(t, func() bool {
+			return atomic.LoadInt64(&running) == c.want
+		}, time.Second, time.Millisecond)
+
+		close(release)
+		require.NoError(t, bg.Close(ctx))
+		require.Equal(t, c.want, atomic.LoadInt64(&running))
+	}
+}
@@ -0,0 +1,133 @@
+package background
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultDrainTimeout Close 默认等待任务退出的时长
+const DefaultDrainTimeout = 5 * time.Second
+
+// ShutdownError 在 Close 超时时返回，列出未能按时退出的任务
+type ShutdownError struct {
+	// 未退出的任务名称
+	Tasks []string
+	// 导致超时的 ctx 错误
+	Err error
+}
+
+func (e *ShutdownError) Error() string {
+	return fmt.Sprintf("background: tasks did not exit in time: %s: %v", strings.Join(e.Tasks, ", "), e.Err)
+}
+
+func (e *ShutdownError) Unwrap() error {
+	return e.Err
+}
+
+type shutdownGroup struct {
+	// 通过 ClosedFromContext 暴露给该分组的任务
+	closed chan struct{}
+	once   sync.Once
+}
+
+// group 返回 id 对应的关闭分组，调用者需要持有 bg.mu
+func (bg *Background) group(id int) *shutdownGroup {
+	g, ok := bg.groups[id]
+	if !ok {
+		g = &shutdownGroup{closed: make(chan struct{})}
+		bg.groups[id] = g
+	}
+	return g
+}
+
+// Close 按照关闭分组依次通知任务退出并等待它们退出，可以重复调用。
+// 任务的 context 会被取消，ClosedFromContext 返回的 channel 会被 close，
+// 超时后返回 *ShutdownError
+func (bg *Background) Close(ctx context.Context) error {
+	if bg.drainTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, bg.drainTimeout)
+		defer cancel()
+	}
+
+	bg.mu.Lock()
+	bg.closeOnce.Do(func() {
+		close(bg.closed)
+	})
+	byGroup := make(map[int][]*task)
+	for _, t := range bg.tasks {
+		byGroup[t.opts.group] = append(byGroup[t.opts.group], t)
+	}
+	bg.mu.Unlock()
+
+	var pending []string
+	for _, id := range slices.Sorted(maps.Keys(byGroup)) {
+		tasks := byGroup[id]
+		bg.stopGroup(id, tasks)
+
+		groupCtx := ctx
+		if bg.groupTimeout > 0 {
+			var cancel context.CancelFunc
+			groupCtx, cancel = context.WithTimeout(ctx, bg.groupTimeout)
+			defer cancel()
+		}
+
+		for _, t := range tasks {
+			select {
+			case <-t.done:
+			case <-groupCtx.Done():
+			}
+		}
+
+		var groupPending []string
+		for _, t := range tasks {
+			select {
+			case <-t.done:
+			default:
+				groupPending = append(groupPending, t.name)
+			}
+		}
+		if len(groupPending) > 0 {
+			bg.log.Errorf("shutdown group %d: tasks did not exit in time: %s", id, strings.Join(groupPending, ", "))
+			pending = append(pending, groupPending...)
+		}
+	}
+
+	if len(pending) > 0 {
+		err := ctx.Err()
+		if err == nil {
+			err = context.DeadlineExceeded
+		}
+		return &ShutdownError{Tasks: pending, Err: err}
+	}
+	return nil
+}
+
+// stopGroup 取消分组内所有任务的 context 并 close 分组的 channel，
+// 从未启动的任务会被直接标记为退出
+func (bg *Background) stopGroup(id int, tasks []*task) {
+	bg.mu.Lock()
+	defer bg.mu.Unlock()
+
+	for _, t := range tasks {
+		t.mu.Lock()
+		cancel := t.cancel
+		t.mu.Unlock()
+
+		if cancel == nil {
+			t.finish(nil)
+			continue
+		}
+		cancel()
+	}
+
+	g := bg.group(id)
+	g.once.Do(func() {
+		close(g.closed)
+	})
+}
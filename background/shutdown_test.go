@@ -0,0 +1,122 @@
+package background
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloseCancelsContext(t *testing.T) {
+	bg := New(log.DefaultLogger)
+	h := bg.Add(func(ctx context.Context) {
+		<-ctx.Done()
+	})
+
+	ctx := context.Background()
+	bg.Start(ctx)
+
+	require.NoError(t, bg.Close(ctx))
+	<-h.Done()
+}
+
+func TestShutdownGroupsOrder(t *testing.T) {
+	bg := New(log.DefaultLogger)
+
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	record := func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, name)
+	}
+
+	var producerExited int64
+	for _, name := range []string{"producer-1", "producer-2"} {
+		bg.AddWithOptions(func(ctx context.Context) {
+			<-ctx.Done()
+			time.Sleep(10 * time.Millisecond)
+			record(name)
+			atomic.AddInt64(&producerExited, 1)
+		}, WithName(name))
+	}
+	bg.AddWithOptions(func(ctx context.Context) {
+		closed, _ := ClosedFromContext(ctx)
+		<-closed
+		require.Equal(t, int64(2), atomic.LoadInt64(&producerExited))
+		record("consumer")
+	}, WithName("consumer"), WithShutdownGroup(1))
+
+	ctx := context.Background()
+	bg.Start(ctx)
+	require.NoError(t, bg.Close(ctx))
+
+	require.Len(t, order, 3)
+	require.Equal(t, "consumer", order[2])
+}
+
+func TestCloseTimeoutReport(t *testing.T) {
+	bg := New(log.DefaultLogger, WithDrainTimeout(20*time.Millisecond))
+
+	release := make(chan struct{})
+	defer close(release)
+	bg.AddWithOptions(func(ctx context.Context) {
+		<-release
+	}, WithName("stuck"))
+	bg.AddWithOptions(func(ctx context.Context) {
+		<-ctx.Done()
+	}, WithName("polite"))
+
+	ctx := context.Background()
+	bg.Start(ctx)
+
+	err := bg.Close(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	var shutdownErr *ShutdownError
+	require.ErrorAs(t, err, &shutdownErr)
+	require.Equal(t, []string{"stuck"}, shutdownErr.Tasks)
+}
+
+func TestGroupDrainTimeout(t *testing.T) {
+	bg := New(log.DefaultLogger,
+		WithDrainTimeout(time.Second),
+		WithGroupDrainTimeout(20*time.Millisecond),
+	)
+
+	release := make(chan struct{})
+	defer close(release)
+	bg.AddWithOptions(func(ctx context.Context) {
+		<-release
+	}, WithName("stuck-producer"))
+	consumer := bg.AddWithOptions(func(ctx context.Context) {
+		<-ctx.Done()
+	}, WithName("consumer"), WithShutdownGroup(1))
+
+	ctx := context.Background()
+	bg.Start(ctx)
+
+	start := time.Now()
+	err := bg.Close(ctx)
+	require.Less(t, time.Since(start), time.Second)
+
+	var shutdownErr *ShutdownError
+	require.ErrorAs(t, err, &shutdownErr)
+	require.Equal(t, []string{"stuck-producer"}, shutdownErr.Tasks)
+	<-consumer.Done()
+}
+
+func TestCloseWithoutLaunch(t *testing.T) {
+	bg := New(log.DefaultLogger)
+	h := bg.Add(func(ctx context.Context) {})
+
+	require.NoError(t, bg.Close(context.Background()))
+	<-h.Done()
+	require.Equal(t, TaskStopped, h.Status().State)
+}
@@ -0,0 +1,164 @@
+package background
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+)
+
+// TaskState 任务当前所处的状态
+type TaskState string
+
+const (
+	// TaskPending 已添加但尚未启动
+	TaskPending TaskState = "pending"
+	// TaskRunning 正在运行
+	TaskRunning TaskState = "running"
+	// TaskWaiting 周期任务正在等待下一次触发
+	TaskWaiting TaskState = "waiting"
+	// TaskRestarting 任务已退出，正在等待重启
+	TaskRestarting TaskState = "restarting"
+	// TaskStopped 任务已退出且不会再运行
+	TaskStopped TaskState = "stopped"
+)
+
+// TaskStatus 某一时刻任务状态的快照
+type TaskStatus struct {
+	Name  string
+	State TaskState
+	// 最近一次运行的开始时间
+	StartedAt time.Time
+	// 任务退出的时间，仅在 TaskStopped 状态下有效
+	StoppedAt time.Time
+	Restarts  int
+	// 最近一次 panic 的值、堆栈和时间
+	LastPanic      any
+	LastPanicStack string
+	LastPanicAt    time.Time
+	// 任务最近一次退出时返回的错误
+	LastError error
+	// 周期任务的下一次触发时间
+	NextRun time.Time
+}
+
+type taskStatusJSON struct {
+	Name           string     `json:"name"`
+	State          TaskState  `json:"state"`
+	StartedAt      *time.Time `json:"started_at,omitempty"`
+	StoppedAt      *time.Time `json:"stopped_at,omitempty"`
+	Restarts       int        `json:"restarts"`
+	LastPanic      string     `json:"last_panic,omitempty"`
+	LastPanicStack string     `json:"last_panic_stack,omitempty"`
+	LastPanicAt    *time.Time `json:"last_panic_at,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	NextRun        *time.Time `json:"next_run,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+// panic 的值和错误会被格式化为字符串，零值时间会被省略
+func (s TaskStatus) MarshalJSON() ([]byte, error) {
+	optionalTime := func(t time.Time) *time.Time {
+		if t.IsZero() {
+			return nil
+		}
+		return &t
+	}
+
+	v := taskStatusJSON{
+		Name:           s.Name,
+		State:          s.State,
+		StartedAt:      optionalTime(s.StartedAt),
+		StoppedAt:      optionalTime(s.StoppedAt),
+		Restarts:       s.Restarts,
+		LastPanicStack: s.LastPanicStack,
+		LastPanicAt:    optionalTime(s.LastPanicAt),
+		NextRun:        optionalTime(s.NextRun),
+	}
+	if s.LastPanic != nil {
+		v.LastPanic = fmt.Sprint(s.LastPanic)
+	}
+	if s.LastError != nil {
+		v.LastError = s.LastError.Error()
+	}
+	return json.Marshal(v)
+}
+
+// Status 返回所有任务的状态快照，顺序与添加顺序一致
+func (bg *Background) Status() []TaskStatus {
+	bg.mu.Lock()
+	tasks := slices.Clone(bg.tasks)
+	bg.mu.Unlock()
+
+	result := make([]TaskStatus, 0, len(tasks))
+	for _, t := range tasks {
+		result = append(result, t.snapshot())
+	}
+	return result
+}
+
+func (t *task) snapshot() TaskStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.status
+}
+
+func (t *task) setState(state TaskState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.status.State = state
+	switch state {
+	case TaskRunning:
+		t.status.StartedAt = time.Now()
+		t.status.StoppedAt = time.Time{}
+	case TaskStopped:
+		t.status.StoppedAt = time.Now()
+		t.status.NextRun = time.Time{}
+	}
+}
+
+func (t *task) recordPanic(p any, stk string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.status.LastPanic = p
+	t.status.LastPanicStack = stk
+	t.status.LastPanicAt = time.Now()
+}
+
+func (t *task) recordRestart() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.status.Restarts++
+	t.status.State = TaskRestarting
+}
+
+func (t *task) recordExit(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.status.LastError = err
+}
+
+func (t *task) setNextRun(next time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.status.NextRun = next
+	t.status.State = TaskWaiting
+}
+
+// funcName 返回 f 的短名称，用作任务的默认名称
+func funcName(f any) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(f).Pointer())
+	if fn == nil {
+		return "unknown"
+	}
+	segments := strings.Split(fn.Name(), "/")
+	return segments[len(segments)-1]
+}
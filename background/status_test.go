@@ -0,0 +1,54 @@
+package background
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatus(t *testing.T) {
+	bg := New(log.DefaultLogger)
+
+	var runs int64
+	bg.AddWithOptions(func(ctx context.Context) {
+		if atomic.AddInt64(&runs, 1) == 1 {
+			panic("boom")
+		}
+	}, WithName("crasher"), WithBackoff(Backoff{Initial: time.Millisecond}))
+
+	block := make(chan struct{})
+	bg.Add(func(ctx context.Context) {
+		<-block
+	})
+
+	status := bg.Status()
+	require.Len(t, status, 2)
+	require.Equal(t, "crasher", status[0].Name)
+	require.Equal(t, TaskPending, status[0].State)
+	require.Contains(t, status[1].Name, "TestStatus")
+
+	ctx := context.Background()
+	bg.Start(ctx)
+
+	require.Eventually(t, func() bool {
+		status := bg.Status()
+		return status[0].State == TaskStopped && status[1].State == TaskRunning
+	}, time.Second, time.Millisecond)
+
+	status = bg.Status()
+	require.Equal(t, 1, status[0].Restarts)
+	require.Equal(t, "boom", status[0].LastPanic)
+	require.Contains(t, status[0].LastPanicStack, "TestStatus")
+	require.False(t, status[0].StoppedAt.IsZero())
+	require.False(t, status[1].StartedAt.IsZero())
+
+	close(block)
+	require.NoError(t, bg.Close(ctx))
+	for _, s := range bg.Status() {
+		require.Equal(t, TaskStopped, s.State)
+	}
+}
@@ -33,6 +33,9 @@ func EncodePKIXPublicKey(pubKey any) ([]byte, error) {
 
 func DecodePKCS8PrivateKey(pemData []byte) (any, error) {
 	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found")
+	}
 	if block.Type != "PRIVATE KEY" {
 		return nil, fmt.Errorf("unexpected block type: %s", block.Type)
 	}
@@ -41,6 +44,9 @@ func DecodePKCS8PrivateKey(pemData []byte) (any, error) {
 
 func DecodePKIXPublicKey(pemData []byte) (any, error) {
 	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found")
+	}
 	if block.Type != "PUBLIC KEY" {
 		return nil, fmt.Errorf("unexpected block type: %s", block.Type)
 	}
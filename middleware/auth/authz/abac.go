@@ -0,0 +1,199 @@
+package authz
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/unkmonster/go-kit/middleware/http/realip"
+	"github.com/unkmonster/go-kit/middleware/reqmeta"
+	"gopkg.in/yaml.v3"
+)
+
+// ReasonPolicyDenied 请求被 ABAC 策略拒绝，或者没有策略允许该请求
+const ReasonPolicyDenied = "POLICY_DENIED"
+
+type Effect string
+
+const (
+	EffectAllow Effect = "allow"
+	EffectDeny  Effect = "deny"
+)
+
+// ABACRule 一条策略，When 为空时总是匹配，表达式语法见 expr.go
+type ABACRule struct {
+	Name   string `json:"name" yaml:"name"`
+	Effect Effect `json:"effect" yaml:"effect"`
+	When   string `json:"when" yaml:"when"`
+}
+
+// ABACPolicy 一组策略，可以从 YAML 或者 JSON 加载，例如
+//
+//	rules:
+//	  - name: moderator-remove-user-order
+//	    effect: allow
+//	    when: >
+//	      "moderator" in subject.roles && resource.type == "order" &&
+//	      action == "REMOVE" && resource.owner_type == "user"
+//	  - name: office-only
+//	    effect: deny
+//	    when: '!cidr(request.ip, "10.0.0.0/8")'
+type ABACPolicy struct {
+	Rules []ABACRule `json:"rules" yaml:"rules"`
+}
+
+// Attributes 策略表达式可以访问的属性
+//   - subject: JWT claims 的字段，例如 subject.sub
+//   - resource: id, type, action, collection, self_hold, owner_id, owner_type
+//   - action: 等同于 resource.action
+//   - request: operation 以及 realip 中间件提取的 ip
+type Attributes struct {
+	Subject  jwt.Claims
+	Resource reqmeta.Resource
+	Action   string
+	Request  map[string]any
+}
+
+type abacRule struct {
+	name   string
+	effect Effect
+	when   node
+}
+
+// ABAC 基于属性的 Authorizer，拒绝优先：
+// 任意一条 deny 策略匹配时拒绝，否则有 allow 策略匹配时允许，都不匹配时拒绝
+type ABAC struct {
+	rules []abacRule
+}
+
+var _ Authorizer = (*ABAC)(nil)
+
+// NewABAC 编译 policy，表达式只在这里编译一次
+func NewABAC(policy ABACPolicy) (*ABAC, error) {
+	rules := make([]abacRule, 0, len(policy.Rules))
+	for i, r := range policy.Rules {
+		name := r.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i)
+		}
+		if r.Effect != EffectAllow && r.Effect != EffectDeny {
+			return nil, fmt.Errorf("authz: abac rule %s: invalid effect %q", name, r.Effect)
+		}
+		var when node = &literalNode{v: true}
+		if r.When != "" {
+			n, err := compileExpr(r.When)
+			if err != nil {
+				return nil, fmt.Errorf("authz: abac rule %s: %w", name, err)
+			}
+			when = n
+		}
+		rules = append(rules, abacRule{name: name, effect: r.Effect, when: when})
+	}
+	return &ABAC{rules: rules}, nil
+}
+
+// LoadABAC 从 YAML 或者 JSON 加载 ABACPolicy 并编译
+func LoadABAC(data []byte) (*ABAC, error) {
+	var policy ABACPolicy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("authz: parse abac policy: %w", err)
+	}
+	return NewABAC(policy)
+}
+
+// LoadABACFile 从文件加载 ABACPolicy 并编译
+func LoadABACFile(path string) (*ABAC, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("authz: read abac policy: %w", err)
+	}
+	return LoadABAC(data)
+}
+
+// Evaluate 对 attrs 求值所有策略，不依赖 context，可以用于测试策略。
+// 表达式求值出错时返回错误
+func (a *ABAC) Evaluate(attrs Attributes) (Decision, error) {
+	env, err := attrs.env()
+	if err != nil {
+		return Decision{}, err
+	}
+
+	var allowedBy string
+	for _, r := range a.rules {
+		v, err := r.when.eval(env)
+		if err != nil {
+			return Decision{}, fmt.Errorf("authz: abac rule %s: %w", r.name, err)
+		}
+		matched, err := truthy(v)
+		if err != nil {
+			return Decision{}, fmt.Errorf("authz: abac rule %s: %w", r.name, err)
+		}
+		if !matched {
+			continue
+		}
+		if r.effect == EffectDeny {
+			d := Deny(ReasonPolicyDenied, "Denied by policy %s", r.name)
+			d.Rule = r.name
+			return d, nil
+		}
+		if allowedBy == "" {
+			allowedBy = r.name
+		}
+	}
+	if allowedBy != "" {
+		d := Allow()
+		d.Rule = allowedBy
+		return d, nil
+	}
+	return Deny(ReasonPolicyDenied, "No policy allows %s %s", attrs.Action, attrs.Resource.ResourceType), nil
+}
+
+// Authorize implements Authorizer.
+func (a *ABAC) Authorize(ctx context.Context, req *Request) (Decision, error) {
+	request := map[string]any{"operation": req.Operation}
+	if ip, ok := realip.FromContext(ctx); ok {
+		request["ip"] = ip
+	}
+	return a.Evaluate(Attributes{
+		Subject:  req.Claims,
+		Resource: req.Resource,
+		Action:   req.Resource.Action,
+		Request:  request,
+	})
+}
+
+func (attrs Attributes) env() (map[string]any, error) {
+	res := attrs.Resource
+	env := map[string]any{
+		"resource": map[string]any{
+			"id":         res.ResourceId,
+			"type":       res.ResourceType,
+			"action":     res.Action,
+			"collection": res.IsCollection,
+			"self_hold":  res.IsSelfHold,
+			"owner_id":   res.OwnerId,
+			"owner_type": res.OwnerType,
+		},
+		"action":  attrs.Action,
+		"request": attrs.Request,
+	}
+
+	if attrs.Subject != nil {
+		data, err := json.Marshal(attrs.Subject)
+		if err != nil {
+			return nil, fmt.Errorf("authz: encode claims: %w", err)
+		}
+		// 保留数字的原始形式，避免大整数丢失精度
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		subject := map[string]any{}
+		if err := dec.Decode(&subject); err != nil {
+			return nil, fmt.Errorf("authz: decode claims: %w", err)
+		}
+		env["subject"] = subject
+	}
+	return env, nil
+}
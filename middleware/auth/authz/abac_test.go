@@ -0,0 +1,175 @@
+package authz
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	authjwt "github.com/unkmonster/go-kit/middleware/auth/jwt"
+	"github.com/unkmonster/go-kit/middleware/http/realip"
+	"github.com/unkmonster/go-kit/middleware/reqmeta"
+)
+
+const testABACPolicy = `
+rules:
+  - name: moderator-remove-user-order
+    effect: allow
+    when: >
+      "moderator" in subject.roles && resource.type == "order" &&
+      action == "REMOVE" && resource.owner_type == "user"
+  - name: owner
+    effect: allow
+    when: resource.self_hold && resource.owner_id == subject.uid
+  - name: office-only
+    effect: deny
+    when: request.ip != null && !cidr(request.ip, "10.0.0.0/8")
+`
+
+func TestExpr(t *testing.T) {
+	env := map[string]any{
+		"subject":  map[string]any{"sub": "u1", "level": int64(3), "roles": []any{"user", "vip"}},
+		"resource": map[string]any{"type": "order", "owner_id": int64(42)},
+		"action":   "GET",
+		"request":  map[string]any{"ip": "10.1.2.3"},
+	}
+	cases := map[string]any{
+		`subject.sub == "u1"`:                     true,
+		`subject.level >= 3 && subject.level < 4`: true,
+		`resource.owner_id == 42.0`:               true,
+		`"vip" in subject.roles`:                  true,
+		`action in ["GET", "LIST"]`:               true,
+		`lower(action) == 'get'`:                  true,
+		`!(action == "GET") || false`:             false,
+		`subject.missing == null`:                 true,
+		`subject.missing > 1`:                     false,
+		`subject.missing.deep`:                    nil,
+		`cidr(request.ip, "10.0.0.0/8")`:          true,
+		`cidr(request.ip, "192.168.0.0/16")`:      false,
+		`"ord" in resource.type`:                  true,
+		`[1, 2] == [1, 2.0]`:                      true,
+	}
+	for src, want := range cases {
+		n, err := compileExpr(src)
+		require.NoError(t, err, src)
+		got, err := n.eval(env)
+		require.NoError(t, err, src)
+		require.Equal(t, want, got, src)
+	}
+
+	// 编译错误
+	for _, src := range []string{
+		`user.id == 1`,
+		`action ==`,
+		`(action == "GET"`,
+		`unknown(action)`,
+		`cidr(request.ip, "bad")`,
+		`lower(action, action)`,
+		`action == "GET`,
+		`action # 1`,
+		`action == "GET" action`,
+	} {
+		_, err := compileExpr(src)
+		require.Error(t, err, src)
+	}
+
+	// 求值错误
+	for _, src := range []string{
+		`action < 1`,
+		`action && true`,
+		`1 in action`,
+	} {
+		n, err := compileExpr(src)
+		require.NoError(t, err, src)
+		_, err = n.eval(env)
+		require.Error(t, err, src)
+	}
+}
+
+func TestABACEvaluate(t *testing.T) {
+	abac, err := LoadABAC([]byte(testABACPolicy))
+	require.NoError(t, err)
+
+	remove := reqmeta.Resource{ResourceType: "order", Action: "REMOVE", OwnerId: int64(42), OwnerType: "user"}
+	moderator := jwt.MapClaims{"sub": "m1", "roles": []string{"moderator"}}
+
+	d, err := abac.Evaluate(Attributes{Subject: moderator, Resource: remove, Action: "REMOVE"})
+	require.NoError(t, err)
+	require.True(t, d.Allow)
+
+	// owner 类型不是 user
+	admin := remove
+	admin.OwnerType = "admin"
+	d, err = abac.Evaluate(Attributes{Subject: moderator, Resource: admin, Action: "REMOVE"})
+	require.NoError(t, err)
+	require.False(t, d.Allow)
+	require.Equal(t, ReasonPolicyDenied, d.Reason)
+
+	// 数字类型的 claim 与 int64 类型的 OwnerId 比较
+	self := reqmeta.Resource{ResourceType: "order", Action: "GET", IsSelfHold: true, OwnerId: int64(42)}
+	d, err = abac.Evaluate(Attributes{Subject: jwt.MapClaims{"uid": 42}, Resource: self, Action: "GET"})
+	require.NoError(t, err)
+	require.True(t, d.Allow)
+
+	// deny 优先于 allow
+	d, err = abac.Evaluate(Attributes{
+		Subject:  moderator,
+		Resource: remove,
+		Action:   "REMOVE",
+		Request:  map[string]any{"ip": "203.0.113.1"},
+	})
+	require.NoError(t, err)
+	require.False(t, d.Allow)
+	require.Contains(t, d.Message, "office-only")
+
+	// 对象类型的 claim 和属性可以比较，不会 panic
+	objects, err := LoadABAC([]byte(`
+rules:
+  - name: same-org
+    effect: allow
+    when: subject.org == subject.home && resource == resource && request != subject.org
+`))
+	require.NoError(t, err)
+	org := map[string]any{"id": 1, "tags": []string{"a"}}
+	d, err = objects.Evaluate(Attributes{
+		Subject:  jwt.MapClaims{"org": org, "home": map[string]any{"id": 1, "tags": []string{"a"}}},
+		Resource: self,
+		Request:  map[string]any{"ip": "10.0.0.1"},
+	})
+	require.NoError(t, err)
+	require.True(t, d.Allow)
+	d, err = objects.Evaluate(Attributes{Subject: jwt.MapClaims{"org": org, "home": map[string]any{"id": 2}}})
+	require.NoError(t, err)
+	require.False(t, d.Allow)
+
+	_, err = LoadABAC([]byte(`rules: [{name: bad, effect: maybe}]`))
+	require.Error(t, err)
+	_, err = LoadABAC([]byte(`rules: [{name: bad, effect: allow, when: "action =="}]`))
+	require.ErrorContains(t, err, "bad")
+}
+
+func TestABACServer(t *testing.T) {
+	abac, err := LoadABAC([]byte(testABACPolicy))
+	require.NoError(t, err)
+	handler := Server(abac)(func(ctx context.Context, req any) (any, error) {
+		return "ok", nil
+	})
+
+	ctx := reqmeta.NewContext(context.Background(), reqmeta.Resource{ResourceType: "order", Action: "REMOVE", OwnerType: "user"})
+	ctx = authjwt.NewContext(ctx, jwt.MapClaims{"sub": "m1", "roles": "moderator"})
+
+	_, err = handler(realip.NewContext(ctx, "10.0.0.1"), nil)
+	require.NoError(t, err)
+
+	_, err = handler(realip.NewContext(ctx, "203.0.113.1"), nil)
+	require.True(t, errors.IsForbidden(err))
+	require.Equal(t, ReasonPolicyDenied, errors.Reason(err))
+}
+
+func TestEqualUncomparable(t *testing.T) {
+	require.True(t, equal(map[string]any{"a": []any{int64(1)}}, map[string]any{"a": []any{1.0}}))
+	require.False(t, equal(map[string]any{"a": 1}, map[string]any{"b": 1}))
+	require.False(t, equal([]string{"a"}, []string{"a"}))
+	require.False(t, equal(map[string]string{}, "a"))
+}
@@ -0,0 +1,96 @@
+package authz
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/unkmonster/go-kit/middleware/http/realip"
+)
+
+// AuditEvent 一次授权决定
+type AuditEvent struct {
+	Time         time.Time
+	Operation    string
+	Subject      string
+	ResourceType string
+	ResourceId   string
+	Action       string
+	// 做出决定的规则，见 Decision.Rule
+	Rule  string
+	Allow bool
+	// 拒绝时的 reason 和原因
+	Reason   string
+	Message  string
+	ClientIP string
+}
+
+// AuditSink 接收授权决定，Emit 在请求的处理路径上被调用，不应当阻塞
+type AuditSink interface {
+	Emit(ctx context.Context, event *AuditEvent)
+}
+
+// WithAuditSink 将每一次授权决定发送到 sink，可以指定多次
+func WithAuditSink(sink AuditSink) Option {
+	return func(o *options) {
+		o.sinks = append(o.sinks, sink)
+	}
+}
+
+func (o *options) audit(ctx context.Context, req *Request, d Decision) {
+	if len(o.sinks) == 0 {
+		return
+	}
+
+	res := req.Resource
+	event := &AuditEvent{
+		Time:         time.Now(),
+		Operation:    req.Operation,
+		Subject:      req.Subject,
+		ResourceType: res.ResourceType,
+		Action:       res.Action,
+		Rule:         d.Rule,
+		Allow:        d.Allow,
+		Reason:       d.Reason,
+		Message:      d.Message,
+	}
+	event.ResourceId, _ = idString(res.ResourceId)
+	event.ClientIP, _ = realip.FromContext(ctx)
+	if !d.Allow && event.Reason == "" {
+		event.Reason = ReasonPermissionDenied
+	}
+
+	for _, sink := range o.sinks {
+		sink.Emit(ctx, event)
+	}
+}
+
+type logSink struct {
+	logger log.Logger
+}
+
+// NewLogSink 以结构化日志输出授权决定，允许的请求为 INFO 级别，拒绝的请求为 WARN 级别
+func NewLogSink(logger log.Logger) AuditSink {
+	return &logSink{logger: logger}
+}
+
+// Emit implements AuditSink.
+func (s *logSink) Emit(ctx context.Context, e *AuditEvent) {
+	level := log.LevelInfo
+	if !e.Allow {
+		level = log.LevelWarn
+	}
+	_ = log.WithContext(ctx, s.logger).Log(level,
+		"msg", "authz decision",
+		"operation", e.Operation,
+		"subject", e.Subject,
+		"resource_type", e.ResourceType,
+		"resource_id", e.ResourceId,
+		"action", e.Action,
+		"rule", e.Rule,
+		"allow", e.Allow,
+		"reason", e.Reason,
+		"message", e.Message,
+		"client_ip", e.ClientIP,
+	)
+}
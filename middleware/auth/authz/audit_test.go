@@ -0,0 +1,67 @@
+package authz
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	authjwt "github.com/unkmonster/go-kit/middleware/auth/jwt"
+	"github.com/unkmonster/go-kit/middleware/http/realip"
+	"github.com/unkmonster/go-kit/middleware/reqmeta"
+)
+
+type recordSink struct {
+	events []*AuditEvent
+}
+
+func (s *recordSink) Emit(_ context.Context, e *AuditEvent) {
+	s.events = append(s.events, e)
+}
+
+func TestAudit(t *testing.T) {
+	rbac, err := LoadRBAC([]byte(testPolicy))
+	require.NoError(t, err)
+
+	sink := &recordSink{}
+	var buf bytes.Buffer
+	handler := Server(rbac, WithAuditSink(sink), WithAuditSink(NewLogSink(log.NewStdLogger(&buf))))(
+		func(ctx context.Context, req any) (any, error) {
+			return nil, nil
+		})
+
+	ctx := reqmeta.NewContext(context.Background(), reqmeta.Resource{ResourceType: "order", ResourceId: int64(7), Action: "GET"})
+	ctx = realip.NewContext(ctx, "10.0.0.1")
+
+	_, err = handler(authjwt.NewContext(ctx, jwt.MapClaims{"sub": "u1", "roles": "user"}), nil)
+	require.NoError(t, err)
+	_, err = handler(authjwt.NewContext(ctx, jwt.MapClaims{"sub": "u2"}), nil)
+	require.Error(t, err)
+	_, err = handler(ctx, nil)
+	require.Error(t, err)
+
+	require.Len(t, sink.events, 3)
+	allowed := sink.events[0]
+	require.True(t, allowed.Allow)
+	require.Equal(t, "u1", allowed.Subject)
+	require.Equal(t, "order", allowed.ResourceType)
+	require.Equal(t, "7", allowed.ResourceId)
+	require.Equal(t, "GET", allowed.Action)
+	require.Equal(t, "user:order", allowed.Rule)
+	require.Equal(t, "10.0.0.1", allowed.ClientIP)
+	require.False(t, allowed.Time.IsZero())
+
+	denied := sink.events[1]
+	require.False(t, denied.Allow)
+	require.Equal(t, "u2", denied.Subject)
+	require.Equal(t, ReasonPermissionDenied, denied.Reason)
+	require.Empty(t, denied.Rule)
+
+	require.Equal(t, ReasonUnauthenticated, sink.events[2].Reason)
+
+	require.Contains(t, buf.String(), "INFO msg=authz decision")
+	require.Contains(t, buf.String(), "WARN msg=authz decision")
+	require.Contains(t, buf.String(), "rule=user:order")
+}
@@ -1 +1,189 @@
+// Package authz 在 jwt 认证之后，根据 reqmeta 提取的资源信息进行授权
 package authz
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/golang-jwt/jwt/v5"
+	authjwt "github.com/unkmonster/go-kit/middleware/auth/jwt"
+	"github.com/unkmonster/go-kit/middleware/reqmeta"
+)
+
+const (
+	// ReasonPermissionDenied 请求者的角色没有对应的权限
+	ReasonPermissionDenied = "PERMISSION_DENIED"
+	// ReasonUnauthenticated 请求中没有认证信息
+	ReasonUnauthenticated = "UNAUTHENTICATED"
+	// ReasonAuthorizationFailed Authorizer 返回了错误
+	ReasonAuthorizationFailed = "AUTHORIZATION_FAILED"
+)
+
+var (
+	ErrPermissionDenied = errors.Forbidden(ReasonPermissionDenied, "Permission denied")
+	ErrUnauthenticated  = errors.Unauthorized(ReasonUnauthenticated, "Authentication is required")
+)
+
+// Request 一次授权请求
+type Request struct {
+	// 来自 jwt.FromContext，未认证时为 nil
+	Claims  jwt.Claims
+	Subject string
+	Roles   []string
+	// 来自 reqmeta.FromContext
+	Resource  reqmeta.Resource
+	Operation string
+}
+
+// Decision 授权结果
+type Decision struct {
+	Allow bool
+	// 拒绝时作为 Kratos 错误的 reason，为空时使用 ReasonPermissionDenied
+	Reason string
+	// 拒绝的原因，用于错误信息和日志
+	Message string
+	// 允许时注入上下文的等值过滤条件，通过 FiltersFrom 取出
+	Filters map[string]any
+	// 做出该决定的规则，用于审计，没有规则匹配时为空
+	Rule string
+}
+
+// Allow 允许请求
+func Allow() Decision {
+	return Decision{Allow: true}
+}
+
+// Deny 以 reason 拒绝请求
+func Deny(reason, format string, args ...any) Decision {
+	return Decision{Reason: reason, Message: fmt.Sprintf(format, args...)}
+}
+
+// Authorizer 授权引擎
+type Authorizer interface {
+	Authorize(ctx context.Context, req *Request) (Decision, error)
+}
+
+// AuthorizerFunc 函数形式的 Authorizer
+type AuthorizerFunc func(ctx context.Context, req *Request) (Decision, error)
+
+// Authorize implements Authorizer.
+func (f AuthorizerFunc) Authorize(ctx context.Context, req *Request) (Decision, error) {
+	return f(ctx, req)
+}
+
+// All 依次执行 authorizers，任意一个拒绝时拒绝请求，全部允许时合并它们的过滤条件
+func All(authorizers ...Authorizer) Authorizer {
+	return AuthorizerFunc(func(ctx context.Context, req *Request) (Decision, error) {
+		result := Allow()
+		var rules []string
+		for _, a := range authorizers {
+			d, err := a.Authorize(ctx, req)
+			if err != nil || !d.Allow {
+				return d, err
+			}
+			if d.Rule != "" {
+				rules = append(rules, d.Rule)
+			}
+			if len(d.Filters) > 0 {
+				if result.Filters == nil {
+					result.Filters = map[string]any{}
+				}
+				maps.Copy(result.Filters, d.Filters)
+			}
+		}
+		result.Rule = strings.Join(rules, ",")
+		return result, nil
+	})
+}
+
+type options struct {
+	roleClaim string
+	sinks     []AuditSink
+}
+
+type Option func(o *options)
+
+// WithRoleClaim 指定保存角色的 claim，值可以是空格分隔的字符串或者字符串数组，默认为 roles
+func WithRoleClaim(name string) Option {
+	return func(o *options) {
+		o.roleClaim = name
+	}
+}
+
+// Server 授权中间件，需要放在 jwt.Server 和 reqmeta.Server 之后。
+// 没有资源类型的请求（未添加 reqmeta 注解）不做检查，
+// 未认证的请求返回 ErrUnauthenticated，被拒绝的请求返回以 Decision.Reason 为 reason 的 Forbidden 错误
+func Server(authorizer Authorizer, opts ...Option) middleware.Middleware {
+	o := &options{
+		roleClaim: "roles",
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			res, ok := reqmeta.FromContext(ctx)
+			if !ok || res.ResourceType == "" {
+				return handler(ctx, req)
+			}
+
+			areq := &Request{Resource: res}
+			if tr, ok := transport.FromServerContext(ctx); ok {
+				areq.Operation = tr.Operation()
+			}
+
+			claims, ok := authjwt.FromContext(ctx)
+			if !ok {
+				o.audit(ctx, areq, Decision{Reason: ReasonUnauthenticated, Message: ErrUnauthenticated.Message})
+				return nil, ErrUnauthenticated
+			}
+			areq.Claims = claims
+			areq.Roles = authjwt.ClaimStringsFrom(ctx, o.roleClaim)
+			areq.Subject, _ = authjwt.SubjectFrom(ctx)
+
+			decision, err := authorizer.Authorize(ctx, areq)
+			if err != nil {
+				o.audit(ctx, areq, Decision{Reason: ReasonAuthorizationFailed, Message: err.Error()})
+				return nil, errors.InternalServer(ReasonAuthorizationFailed, "Can not authorize request").WithCause(err)
+			}
+			o.audit(ctx, areq, decision)
+			if !decision.Allow {
+				return nil, denyError(decision, res)
+			}
+			if len(decision.Filters) > 0 {
+				ctx = context.WithValue(ctx, filtersKey{}, decision.Filters)
+			}
+			return handler(ctx, req)
+		}
+	}
+}
+
+type filtersKey struct{}
+
+// FiltersFrom 返回授权时注入的过滤条件，可以直接用于 filter.WithEqualValues
+func FiltersFrom(ctx context.Context) map[string]any {
+	filters, _ := ctx.Value(filtersKey{}).(map[string]any)
+	return filters
+}
+
+// denyError 将拒绝的 Decision 转换为 Kratos Forbidden 错误，metadata 中包含资源类型和操作
+func denyError(d Decision, res reqmeta.Resource) *errors.Error {
+	reason := d.Reason
+	if reason == "" {
+		reason = ReasonPermissionDenied
+	}
+	message := d.Message
+	if message == "" {
+		message = ErrPermissionDenied.Message
+	}
+	return errors.Forbidden(reason, message).WithMetadata(map[string]string{
+		"resource": res.ResourceType,
+		"action":   res.Action,
+	})
+}
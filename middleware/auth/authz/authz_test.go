@@ -0,0 +1,120 @@
+package authz
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	authjwt "github.com/unkmonster/go-kit/middleware/auth/jwt"
+	"github.com/unkmonster/go-kit/middleware/reqmeta"
+)
+
+const testPolicy = `
+roles:
+  admin:
+    - resource: "*"
+      actions: ["*"]
+  user:
+    - resource: order
+      actions: [get, create]
+`
+
+func serve(t *testing.T, authorizer Authorizer, claims jwt.Claims, res *reqmeta.Resource, opts ...Option) error {
+	t.Helper()
+	ctx := context.Background()
+	if claims != nil {
+		ctx = authjwt.NewContext(ctx, claims)
+	}
+	if res != nil {
+		ctx = reqmeta.NewContext(ctx, *res)
+	}
+	_, err := Server(authorizer, opts...)(func(ctx context.Context, req any) (any, error) {
+		return "ok", nil
+	})(ctx, nil)
+	return err
+}
+
+func TestRBACAllowed(t *testing.T) {
+	rbac, err := LoadRBAC([]byte(testPolicy))
+	require.NoError(t, err)
+
+	require.True(t, rbac.Allowed([]string{"user"}, "order", "GET"))
+	require.True(t, rbac.Allowed([]string{"guest", "user"}, "order", "create"))
+	require.False(t, rbac.Allowed([]string{"user"}, "order", "REMOVE"))
+	require.False(t, rbac.Allowed([]string{"user"}, "user", "GET"))
+	require.True(t, rbac.Allowed([]string{"admin"}, "user", "REMOVE"))
+	require.False(t, rbac.Allowed(nil, "order", "GET"))
+}
+
+func TestLoadRBAC(t *testing.T) {
+	// JSON 是 YAML 的子集
+	rbac, err := LoadRBAC([]byte(`{"roles": {"user": [{"resource": "order", "actions": ["GET"]}]}}`))
+	require.NoError(t, err)
+	require.True(t, rbac.Allowed([]string{"user"}, "order", "get"))
+
+	_, err = LoadRBAC([]byte(`{"roles": {"user": [{"actions": ["GET"]}]}}`))
+	require.Error(t, err)
+	_, err = LoadRBAC([]byte(`roles: [`))
+	require.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "rbac.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testPolicy), 0o600))
+	rbac, err = LoadRBACFile(path)
+	require.NoError(t, err)
+	require.True(t, rbac.Allowed([]string{"admin"}, "order", "REMOVE"))
+}
+
+func TestServer(t *testing.T) {
+	rbac, err := LoadRBAC([]byte(testPolicy))
+	require.NoError(t, err)
+
+	user := jwt.MapClaims{"sub": "u1", "roles": []any{"user"}}
+	get := &reqmeta.Resource{ResourceType: "order", Action: "GET"}
+	remove := &reqmeta.Resource{ResourceType: "order", Action: "REMOVE"}
+
+	require.NoError(t, serve(t, rbac, user, get))
+
+	err = serve(t, rbac, user, remove)
+	require.True(t, errors.IsForbidden(err))
+	e := errors.FromError(err)
+	require.Equal(t, ReasonPermissionDenied, e.Reason)
+	require.Equal(t, "order", e.Metadata["resource"])
+	require.Equal(t, "REMOVE", e.Metadata["action"])
+
+	// 没有资源信息的请求不检查
+	require.NoError(t, serve(t, rbac, nil, nil))
+
+	err = serve(t, rbac, nil, get)
+	require.True(t, errors.IsUnauthorized(err))
+	require.Equal(t, ReasonUnauthenticated, errors.Reason(err))
+
+	// 空格分隔的角色
+	admin := jwt.MapClaims{"sub": "u2", "role": "guest admin"}
+	require.NoError(t, serve(t, rbac, admin, remove, WithRoleClaim("role")))
+	require.True(t, errors.IsForbidden(serve(t, rbac, admin, remove)))
+}
+
+func TestServerAuthorizerFunc(t *testing.T) {
+	var got *Request
+	deny := AuthorizerFunc(func(ctx context.Context, req *Request) (Decision, error) {
+		got = req
+		return Deny("CUSTOM", "denied for %s", req.Subject), nil
+	})
+
+	err := serve(t, deny, jwt.MapClaims{"sub": "u1"}, &reqmeta.Resource{ResourceType: "order", Action: "GET"})
+	require.Equal(t, "CUSTOM", errors.Reason(err))
+	require.Equal(t, "denied for u1", errors.FromError(err).Message)
+	require.Equal(t, "u1", got.Subject)
+	require.Empty(t, got.Roles)
+
+	failed := AuthorizerFunc(func(ctx context.Context, req *Request) (Decision, error) {
+		return Decision{}, context.DeadlineExceeded
+	})
+	err = serve(t, failed, jwt.MapClaims{"sub": "u1"}, &reqmeta.Resource{ResourceType: "order", Action: "GET"})
+	require.Equal(t, 500, errors.Code(err))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,645 @@
+package authz
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// 表达式语言
+//
+//	expr    = and { "||" and }
+//	and     = not { "&&" not }
+//	not     = "!" not | compare
+//	compare = primary [ ( "==" | "!=" | "<" | "<=" | ">" | ">=" | "in" ) primary ]
+//	primary = literal | path | call | list | "(" expr ")"
+//	path    = ( "subject" | "resource" | "action" | "request" ) { "." ident }
+//	call    = ( "cidr" | "lower" | "upper" ) "(" expr { "," expr } ")"
+//	list    = "[" [ expr { "," expr } ] "]"
+//
+// 字面量支持字符串（单引号或双引号）、数字、true、false 和 null。
+// 不存在的属性为 null，null 与任何值的大小比较都为 false。
+// x in list 判断 list 中是否有元素等于 x，x in str 判断 str 是否包含子串 x
+
+// 表达式中可以使用的根属性
+var exprRoots = map[string]bool{
+	"subject":  true,
+	"resource": true,
+	"action":   true,
+	"request":  true,
+}
+
+type node interface {
+	eval(env map[string]any) (any, error)
+}
+
+// compileExpr 将表达式编译为可以重复求值的语法树
+func compileExpr(src string) (node, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+	}
+	return n, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+var compareOps = map[string]bool{
+	"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true, "in": true,
+}
+
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")", "[", "]", ",", "."}
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '_' || unicode.IsLetter(c):
+			start := i
+			for i < len(src) && (src[i] == '_' || unicode.IsLetter(rune(src[i])) || unicode.IsDigit(rune(src[i]))) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: src[start:i], pos: start})
+		case unicode.IsDigit(c) || c == '-' && i+1 < len(src) && unicode.IsDigit(rune(src[i+1])):
+			start := i
+			i++
+			for i < len(src) && (unicode.IsDigit(rune(src[i])) || src[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: src[start:i], pos: start})
+		case c == '"' || c == '\'':
+			start := i
+			var sb strings.Builder
+			for i++; ; i++ {
+				if i >= len(src) {
+					return nil, fmt.Errorf("unterminated string at %d", start)
+				}
+				if src[i] == '\\' && i+1 < len(src) {
+					i++
+					sb.WriteByte(src[i])
+					continue
+				}
+				if rune(src[i]) == c {
+					i++
+					break
+				}
+				sb.WriteByte(src[i])
+			}
+			tokens = append(tokens, token{kind: tokString, text: sb.String(), pos: start})
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(src[i:], op) {
+					tokens = append(tokens, token{kind: tokOp, text: op, pos: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q at %d", c, i)
+			}
+		}
+	}
+	return append(tokens, token{kind: tokEOF, text: "EOF", pos: len(src)}), nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept 当下一个 token 为操作符 op 时消耗它
+func (p *parser) accept(op string) bool {
+	if t := p.peek(); t.kind == tokOp && t.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(op string) error {
+	if !p.accept(op) {
+		t := p.peek()
+		return fmt.Errorf("expected %q but got %q at %d", op, t.text, t.pos)
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{or: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.accept("!") {
+		n, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{n: n}, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *parser) parseCompare() (node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	if (t.kind != tokOp && t.kind != tokIdent) || !compareOps[t.text] {
+		return left, nil
+	}
+	p.next()
+	right, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	return &compareNode{op: t.text, left: left, right: right}, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		if i, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+			return &literalNode{v: i}, nil
+		}
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at %d", t.text, t.pos)
+		}
+		return &literalNode{v: f}, nil
+	case tokString:
+		return &literalNode{v: t.text}, nil
+	case tokOp:
+		switch t.text {
+		case "(":
+			n, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return n, p.expect(")")
+		case "[":
+			items, err := p.parseList("]")
+			if err != nil {
+				return nil, err
+			}
+			return &listNode{items: items}, nil
+		}
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return &literalNode{v: true}, nil
+		case "false":
+			return &literalNode{v: false}, nil
+		case "null":
+			return &literalNode{v: nil}, nil
+		}
+		if p.accept("(") {
+			args, err := p.parseList(")")
+			if err != nil {
+				return nil, err
+			}
+			return newCallNode(t, args)
+		}
+		if !exprRoots[t.text] {
+			return nil, fmt.Errorf("unknown attribute %q at %d", t.text, t.pos)
+		}
+		path := &pathNode{root: t.text}
+		for p.accept(".") {
+			field := p.next()
+			if field.kind != tokIdent {
+				return nil, fmt.Errorf("expected field name but got %q at %d", field.text, field.pos)
+			}
+			path.fields = append(path.fields, field.text)
+		}
+		return path, nil
+	}
+	return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+}
+
+// parseList 解析以 end 结尾、逗号分隔的表达式列表，起始的括号已经被消耗
+func (p *parser) parseList(end string) ([]node, error) {
+	var items []node
+	if p.accept(end) {
+		return items, nil
+	}
+	for {
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, n)
+		if p.accept(end) {
+			return items, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+type literalNode struct {
+	v any
+}
+
+func (n *literalNode) eval(map[string]any) (any, error) {
+	return n.v, nil
+}
+
+type pathNode struct {
+	root   string
+	fields []string
+}
+
+func (n *pathNode) eval(env map[string]any) (any, error) {
+	v := env[n.root]
+	for _, f := range n.fields {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, nil
+		}
+		v = m[f]
+	}
+	return v, nil
+}
+
+type listNode struct {
+	items []node
+}
+
+func (n *listNode) eval(env map[string]any) (any, error) {
+	list := make([]any, len(n.items))
+	for i, item := range n.items {
+		v, err := item.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		list[i] = v
+	}
+	return list, nil
+}
+
+type notNode struct {
+	n node
+}
+
+func (n *notNode) eval(env map[string]any) (any, error) {
+	v, err := n.n.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	b, err := truthy(v)
+	return !b, err
+}
+
+type logicalNode struct {
+	or          bool
+	left, right node
+}
+
+func (n *logicalNode) eval(env map[string]any) (any, error) {
+	v, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	b, err := truthy(v)
+	if err != nil {
+		return nil, err
+	}
+	// 短路求值
+	if b == n.or {
+		return b, nil
+	}
+	v, err = n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	return truthy(v)
+}
+
+type compareNode struct {
+	op          string
+	left, right node
+}
+
+func (n *compareNode) eval(env map[string]any) (any, error) {
+	l, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	r, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(l, r), nil
+	case "!=":
+		return !equal(l, r), nil
+	case "in":
+		switch r := r.(type) {
+		case nil:
+			return false, nil
+		case []any:
+			for _, item := range r {
+				if equal(l, item) {
+					return true, nil
+				}
+			}
+			return false, nil
+		case string:
+			s, ok := l.(string)
+			if !ok {
+				return nil, fmt.Errorf("in: can not search %T in string", l)
+			}
+			return strings.Contains(r, s), nil
+		default:
+			return nil, fmt.Errorf("in: unsupported operand %T", r)
+		}
+	}
+
+	if l == nil || r == nil {
+		return false, nil
+	}
+	c, err := order(l, r)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	default:
+		return c >= 0, nil
+	}
+}
+
+type callNode struct {
+	name string
+	args []node
+	// cidr 的网段为字面量时在编译时解析
+	network *net.IPNet
+}
+
+var exprFuncs = map[string]int{
+	"cidr":  2,
+	"lower": 1,
+	"upper": 1,
+}
+
+func newCallNode(t token, args []node) (node, error) {
+	arity, ok := exprFuncs[t.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q at %d", t.text, t.pos)
+	}
+	if len(args) != arity {
+		return nil, fmt.Errorf("%s expects %d arguments but got %d at %d", t.text, arity, len(args), t.pos)
+	}
+	n := &callNode{name: t.text, args: args}
+	if lit, ok := args[len(args)-1].(*literalNode); ok && n.name == "cidr" {
+		s, _ := lit.v.(string)
+		_, network, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("cidr: invalid network %v at %d", lit.v, t.pos)
+		}
+		n.network = network
+	}
+	return n, nil
+}
+
+func (n *callNode) eval(env map[string]any) (any, error) {
+	args := make([]any, len(n.args))
+	for i, arg := range n.args {
+		v, err := arg.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+
+	switch n.name {
+	case "cidr":
+		s, _ := args[0].(string)
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return false, nil
+		}
+		network := n.network
+		if network == nil {
+			cidr, _ := args[1].(string)
+			_, parsed, err := net.ParseCIDR(cidr)
+			if err != nil {
+				return nil, fmt.Errorf("cidr: invalid network %v", args[1])
+			}
+			network = parsed
+		}
+		return network.Contains(ip), nil
+	case "lower", "upper":
+		s, ok := args[0].(string)
+		if !ok {
+			return args[0], nil
+		}
+		if n.name == "lower" {
+			return strings.ToLower(s), nil
+		}
+		return strings.ToUpper(s), nil
+	}
+	return nil, fmt.Errorf("unknown function %q", n.name)
+}
+
+// truthy 逻辑运算的操作数必须为布尔值，null 视为 false
+func truthy(v any) (bool, error) {
+	switch v := v.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return v, nil
+	default:
+		return false, fmt.Errorf("expected bool but got %T", v)
+	}
+}
+
+func equal(a, b any) bool {
+	if x, ok := toNumber(a); ok {
+		y, ok := toNumber(b)
+		return ok && compareNumber(x, y) == 0
+	}
+	la, ok := a.([]any)
+	if ok {
+		lb, ok := b.([]any)
+		if !ok || len(la) != len(lb) {
+			return false
+		}
+		for i := range la {
+			if !equal(la[i], lb[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	if _, ok := b.([]any); ok {
+		return false
+	}
+	if ma, ok := a.(map[string]any); ok {
+		mb, ok := b.(map[string]any)
+		if !ok || len(ma) != len(mb) {
+			return false
+		}
+		for k, v := range ma {
+			w, ok := mb[k]
+			if !ok || !equal(v, w) {
+				return false
+			}
+		}
+		return true
+	}
+	// 其他不可比较的类型（例如自定义 claims 中的切片）直接视为不相等，避免 panic
+	if a != nil && !reflect.TypeOf(a).Comparable() || b != nil && !reflect.TypeOf(b).Comparable() {
+		return false
+	}
+	return a == b
+}
+
+func order(a, b any) (int, error) {
+	if x, ok := toNumber(a); ok {
+		if y, ok := toNumber(b); ok {
+			return compareNumber(x, y), nil
+		}
+	}
+	if x, ok := a.(string); ok {
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y), nil
+		}
+	}
+	return 0, fmt.Errorf("can not compare %T with %T", a, b)
+}
+
+// toNumber 整数统一为 int64，其他数字为 float64
+func toNumber(v any) (any, bool) {
+	switch v := v.(type) {
+	case int64, float64:
+		return v, true
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, true
+		}
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if u := rv.Uint(); u <= math.MaxInt64 {
+			return int64(u), true
+		}
+		return float64(rv.Uint()), true
+	case reflect.Float32:
+		return rv.Float(), true
+	}
+	return nil, false
+}
+
+func compareNumber(a, b any) int {
+	if x, ok := a.(int64); ok {
+		if y, ok := b.(int64); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	x, y := toFloat(a), toFloat(b)
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+func toFloat(v any) float64 {
+	if i, ok := v.(int64); ok {
+		return float64(i)
+	}
+	return v.(float64)
+}
@@ -0,0 +1,210 @@
+// Package gorm 将授权审计事件保存到数据库
+package gorm
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/unkmonster/go-kit/middleware/auth/authz"
+	"gorm.io/gorm"
+)
+
+var _ authz.AuditSink = (*AuditSink)(nil)
+
+// auditRecord 审计表中的一行，字符串字段的长度与 size 一致，Emit 时会被截断
+type auditRecord struct {
+	ID           uint64    `gorm:"primaryKey;autoIncrement"`
+	Time         time.Time `gorm:"not null;index"`
+	Operation    string    `gorm:"size:255"`
+	Subject      string    `gorm:"size:191;index"`
+	ResourceType string    `gorm:"size:64;index"`
+	ResourceId   string    `gorm:"size:191"`
+	Action       string    `gorm:"size:32"`
+	Rule         string    `gorm:"size:255"`
+	Allow        bool
+	Reason       string `gorm:"size:64"`
+	Message      string `gorm:"size:512"`
+	ClientIP     string `gorm:"size:64"`
+}
+
+type options struct {
+	table         string
+	batchSize     int
+	flushInterval time.Duration
+	bufferSize    int
+	logger        log.Logger
+}
+
+type Option func(o *options)
+
+// WithTable 指定审计表的名称，默认为 authz_audit_logs
+func WithTable(table string) Option {
+	return func(o *options) {
+		o.table = table
+	}
+}
+
+// WithBatchSize 指定每次批量写入的最大数量，默认为 100，不是正数时使用默认值
+func WithBatchSize(size int) Option {
+	return func(o *options) {
+		o.batchSize = size
+	}
+}
+
+// WithFlushInterval 指定未满一批时的最长写入间隔，默认为 1 秒，不是正数时使用默认值
+func WithFlushInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.flushInterval = interval
+	}
+}
+
+// WithBufferSize 指定等待写入的事件数量上限，超过时丢弃新的事件，默认为 4096，不是正数时使用默认值
+func WithBufferSize(size int) Option {
+	return func(o *options) {
+		o.bufferSize = size
+	}
+}
+
+// WithLogger 指定记录写入失败和丢弃事件的 logger
+func WithLogger(logger log.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// AuditSink 基于 GORM 的 authz.AuditSink，事件先进入缓冲区，由 Run 异步批量写入
+type AuditSink struct {
+	db    *gorm.DB
+	o     *options
+	log   *log.Helper
+	queue chan *auditRecord
+
+	dropped atomic.Uint64
+	// 保证只有一个 Run 在写入
+	running sync.Mutex
+}
+
+func NewAuditSink(db *gorm.DB, opts ...Option) *AuditSink {
+	o := &options{
+		table:         "authz_audit_logs",
+		batchSize:     100,
+		flushInterval: time.Second,
+		bufferSize:    4096,
+		logger:        log.GetLogger(),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.batchSize <= 0 {
+		o.batchSize = 100
+	}
+	if o.flushInterval <= 0 {
+		o.flushInterval = time.Second
+	}
+	if o.bufferSize <= 0 {
+		o.bufferSize = 4096
+	}
+
+	return &AuditSink{
+		db:    db,
+		o:     o,
+		log:   log.NewHelper(o.logger),
+		queue: make(chan *auditRecord, o.bufferSize),
+	}
+}
+
+// Migrate 创建或更新审计表
+func (s *AuditSink) Migrate(ctx context.Context) error {
+	return s.db.WithContext(ctx).Table(s.o.table).AutoMigrate(&auditRecord{})
+}
+
+// Emit implements authz.AuditSink. 超过列长度的字段会被截断，避免一条事件导致整批写入失败，
+// 缓冲区已满时丢弃事件
+func (s *AuditSink) Emit(_ context.Context, e *authz.AuditEvent) {
+	record := &auditRecord{
+		Time:         e.Time.UTC(),
+		Operation:    truncate(e.Operation, 255),
+		Subject:      truncate(e.Subject, 191),
+		ResourceType: truncate(e.ResourceType, 64),
+		ResourceId:   truncate(e.ResourceId, 191),
+		Action:       truncate(e.Action, 32),
+		Rule:         truncate(e.Rule, 255),
+		Allow:        e.Allow,
+		Reason:       truncate(e.Reason, 64),
+		Message:      truncate(e.Message, 512),
+		ClientIP:     truncate(e.ClientIP, 64),
+	}
+	select {
+	case s.queue <- record:
+	default:
+		if s.dropped.Add(1) == 1 {
+			s.log.Warn("authz audit buffer is full, dropping events")
+		}
+	}
+}
+
+// truncate 将 s 截断为最多 n 个字符，不会截断多字节字符
+func truncate(s string, n int) string {
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+		n--
+	}
+	return s
+}
+
+// Dropped 返回因为缓冲区已满而被丢弃的事件数量
+func (s *AuditSink) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Run 批量写入缓冲区中的事件直到 ctx 结束，结束前写入剩余的事件
+func (s *AuditSink) Run(ctx context.Context) error {
+	s.running.Lock()
+	defer s.running.Unlock()
+
+	ticker := time.NewTicker(s.o.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*auditRecord, 0, s.o.batchSize)
+	flush := func(ctx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+		if err := s.db.WithContext(ctx).Table(s.o.table).Create(batch).Error; err != nil {
+			s.log.Errorf("write %d authz audit events: %v", len(batch), err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case r := <-s.queue:
+			batch = append(batch, r)
+			if len(batch) >= s.o.batchSize {
+				flush(ctx)
+			}
+		case <-ticker.C:
+			flush(ctx)
+		case <-ctx.Done():
+			// ctx 已经结束，使用不会取消的 context 写入剩余的事件
+			ctx = context.WithoutCancel(ctx)
+			for {
+				select {
+				case r := <-s.queue:
+					batch = append(batch, r)
+					if len(batch) >= s.o.batchSize {
+						flush(ctx)
+					}
+				default:
+					flush(ctx)
+					return nil
+				}
+			}
+		}
+	}
+}
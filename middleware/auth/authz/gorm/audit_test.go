@@ -0,0 +1,122 @@
+package gorm
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/unkmonster/go-kit/middleware/auth/authz"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newAuditSink(t *testing.T, opts ...Option) (*AuditSink, *gorm.DB) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "audit.db")), &gorm.Config{})
+	require.NoError(t, err)
+
+	sink := NewAuditSink(db, opts...)
+	require.NoError(t, sink.Migrate(context.Background()))
+	return sink, db
+}
+
+func countAudit(t *testing.T, db *gorm.DB) int64 {
+	var count int64
+	require.NoError(t, db.Table("authz_audit_logs").Count(&count).Error)
+	return count
+}
+
+func TestAuditSink(t *testing.T) {
+	sink, db := newAuditSink(t, WithBatchSize(2), WithFlushInterval(time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- sink.Run(ctx)
+	}()
+
+	event := &authz.AuditEvent{
+		Time:         time.Now(),
+		Operation:    "/order.v1.Order/Get",
+		Subject:      "u1",
+		ResourceType: "order",
+		ResourceId:   "7",
+		Action:       "GET",
+		Rule:         "user:order",
+		Allow:        true,
+		ClientIP:     "10.0.0.1",
+	}
+	for range 3 {
+		sink.Emit(context.Background(), event)
+	}
+
+	// 满一批时立即写入
+	require.Eventually(t, func() bool {
+		return countAudit(t, db) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	// 结束时写入剩余的事件
+	cancel()
+	require.NoError(t, <-done)
+	require.EqualValues(t, 3, countAudit(t, db))
+
+	var record auditRecord
+	require.NoError(t, db.Table("authz_audit_logs").First(&record).Error)
+	require.Equal(t, "u1", record.Subject)
+	require.Equal(t, "user:order", record.Rule)
+	require.True(t, record.Allow)
+	require.Equal(t, "10.0.0.1", record.ClientIP)
+}
+
+func TestAuditSinkFlushInterval(t *testing.T) {
+	sink, db := newAuditSink(t, WithFlushInterval(20*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sink.Run(ctx)
+
+	sink.Emit(context.Background(), &authz.AuditEvent{Time: time.Now(), Subject: "u1", Reason: authz.ReasonPermissionDenied})
+	require.Eventually(t, func() bool {
+		return countAudit(t, db) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestAuditSinkDrop(t *testing.T) {
+	sink, _ := newAuditSink(t, WithBufferSize(1))
+
+	sink.Emit(context.Background(), &authz.AuditEvent{Time: time.Now()})
+	sink.Emit(context.Background(), &authz.AuditEvent{Time: time.Now()})
+	require.EqualValues(t, 1, sink.Dropped())
+
+	// 不是正数时使用默认值
+	sink, _ = newAuditSink(t, WithBufferSize(0))
+	require.Equal(t, 4096, cap(sink.queue))
+}
+
+func TestAuditSinkTruncate(t *testing.T) {
+	sink, db := newAuditSink(t, WithBatchSize(0), WithFlushInterval(0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- sink.Run(ctx)
+	}()
+
+	sink.Emit(context.Background(), &authz.AuditEvent{
+		Time:    time.Now(),
+		Subject: strings.Repeat("用", 200),
+		Action:  strings.Repeat("a", 40),
+		Message: strings.Repeat("m", 600),
+	})
+	cancel()
+	require.NoError(t, <-done)
+
+	// 超过列长度的字段按字符截断
+	var record auditRecord
+	require.NoError(t, db.Table("authz_audit_logs").First(&record).Error)
+	require.Equal(t, strings.Repeat("用", 191), record.Subject)
+	require.Equal(t, strings.Repeat("a", 32), record.Action)
+	require.Len(t, record.Message, 512)
+}
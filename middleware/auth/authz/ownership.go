@@ -0,0 +1,176 @@
+package authz
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ReasonNotOwner 请求者不是资源的拥有者
+const ReasonNotOwner = "NOT_OWNER"
+
+// ownershipRule Ownership 做出的决定在审计中使用的规则名称
+const ownershipRule = "ownership"
+
+type ownershipOptions struct {
+	// owner 类型到保存请求者 ID 的 claim 的映射
+	idClaims       map[string]string
+	defaultIDClaim string
+	typeClaim      string
+	column         string
+	adminRoles     []string
+}
+
+type OwnershipOption func(o *ownershipOptions)
+
+// WithOwnerIDClaim 指定 owner 类型为 ownerType 时保存请求者 ID 的 claim，
+// ownerType 为空时修改默认的 claim，默认为 sub
+func WithOwnerIDClaim(ownerType, claim string) OwnershipOption {
+	return func(o *ownershipOptions) {
+		if ownerType == "" {
+			o.defaultIDClaim = claim
+			return
+		}
+		o.idClaims[ownerType] = claim
+	}
+}
+
+// WithOwnerTypeClaim 指定保存请求者类型（user/admin/...）的 claim，
+// 指定后请求者类型必须与资源的 OwnerType 一致，默认不检查
+func WithOwnerTypeClaim(claim string) OwnershipOption {
+	return func(o *ownershipOptions) {
+		o.typeClaim = claim
+	}
+}
+
+// WithOwnerColumn 指定注入的 owner 过滤条件使用的列名，默认为 owner_id
+func WithOwnerColumn(column string) OwnershipOption {
+	return func(o *ownershipOptions) {
+		o.column = column
+	}
+}
+
+// WithAdminRoles 拥有其中任意一个角色的请求者可以访问所有资源
+func WithAdminRoles(roles ...string) OwnershipOption {
+	return func(o *ownershipOptions) {
+		o.adminRoles = roles
+	}
+}
+
+// Ownership 检查 self-hold 资源的拥有者，非 self-hold 的资源直接允许。
+// 资源带有 OwnerId 时必须与请求者的 ID 一致；
+// 通过检查的请求会注入 owner 过滤条件，集合请求可以通过 FiltersFrom 只查询请求者持有的资源
+type Ownership struct {
+	o *ownershipOptions
+}
+
+var _ Authorizer = (*Ownership)(nil)
+
+func NewOwnership(opts ...OwnershipOption) *Ownership {
+	o := &ownershipOptions{
+		idClaims:       map[string]string{},
+		defaultIDClaim: "sub",
+		column:         "owner_id",
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &Ownership{o: o}
+}
+
+// Authorize implements Authorizer.
+func (w *Ownership) Authorize(_ context.Context, req *Request) (Decision, error) {
+	res := req.Resource
+	if !res.IsSelfHold {
+		return Allow(), nil
+	}
+	for _, role := range req.Roles {
+		if slices.Contains(w.o.adminRoles, role) {
+			d := Allow()
+			d.Rule = ownershipRule + ":" + role
+			return d, nil
+		}
+	}
+
+	if w.o.typeClaim != "" && res.OwnerType != "" {
+		typ, _ := claimString(req.Claims, w.o.typeClaim)
+		if typ != res.OwnerType {
+			return w.deny("%s %v can only be accessed by %s", res.ResourceType, res.ResourceId, res.OwnerType), nil
+		}
+	}
+
+	claim := w.o.defaultIDClaim
+	if c, ok := w.o.idClaims[res.OwnerType]; ok {
+		claim = c
+	}
+	id, ok := claimString(req.Claims, claim)
+	if !ok || id == "" {
+		return w.deny("Missing owner claim %s", claim), nil
+	}
+
+	// 批量请求的 OwnerId 为 []any，所有资源都必须属于请求者
+	owners, ok := res.OwnerId.([]any)
+	if !ok {
+		owners = []any{res.OwnerId}
+	}
+	for _, v := range owners {
+		if owner, ok := idString(v); ok && owner != id {
+			return w.deny("%s is not owned by requester", res.ResourceType), nil
+		}
+	}
+	return Decision{Allow: true, Filters: map[string]any{w.o.column: id}, Rule: ownershipRule}, nil
+}
+
+func (w *Ownership) deny(format string, args ...any) Decision {
+	d := Deny(ReasonNotOwner, format, args...)
+	d.Rule = ownershipRule
+	return d
+}
+
+// claimString 读取 claims 中名为 name 的字段，数字以 JSON 中的原始形式返回
+func claimString(claims jwt.Claims, name string) (string, bool) {
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return "", false
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return "", false
+	}
+	raw, ok := fields[name]
+	if !ok {
+		return "", false
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, true
+	}
+	var n json.Number
+	if err := json.Unmarshal(raw, &n); err == nil {
+		return n.String(), true
+	}
+	return "", false
+}
+
+// idString 将 ResourceId 或者 OwnerId 转换为字符串，零值视为未指定
+func idString(v any) (string, bool) {
+	if v == nil || reflect.ValueOf(v).IsZero() {
+		return "", false
+	}
+	switch id := v.(type) {
+	case string:
+		return id, true
+	case int64:
+		return strconv.FormatInt(id, 10), true
+	case uint64:
+		return strconv.FormatUint(id, 10), true
+	default:
+		return fmt.Sprint(id), true
+	}
+}
@@ -0,0 +1,101 @@
+package authz
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	authjwt "github.com/unkmonster/go-kit/middleware/auth/jwt"
+	"github.com/unkmonster/go-kit/middleware/reqmeta"
+)
+
+func TestOwnership(t *testing.T) {
+	w := NewOwnership(
+		WithOwnerIDClaim("user", "uid"),
+		WithOwnerTypeClaim("typ"),
+		WithOwnerColumn("user_id"),
+		WithAdminRoles("admin"),
+	)
+	user := jwt.MapClaims{"sub": "u1", "uid": 42, "typ": "user"}
+	order := reqmeta.Resource{ResourceType: "order", IsSelfHold: true, OwnerId: int64(42), OwnerType: "user"}
+
+	d, err := w.Authorize(context.Background(), &Request{Claims: user, Resource: order})
+	require.NoError(t, err)
+	require.True(t, d.Allow)
+	require.Equal(t, map[string]any{"user_id": "42"}, d.Filters)
+
+	// 其他用户的资源
+	other := order
+	other.OwnerId = int64(7)
+	d, err = w.Authorize(context.Background(), &Request{Claims: user, Resource: other})
+	require.NoError(t, err)
+	require.False(t, d.Allow)
+	require.Equal(t, ReasonNotOwner, d.Reason)
+
+	// 批量请求
+	batch := order
+	batch.OwnerId = []any{int64(42), int64(42)}
+	d, err = w.Authorize(context.Background(), &Request{Claims: user, Resource: batch})
+	require.NoError(t, err)
+	require.True(t, d.Allow)
+	batch.OwnerId = []any{int64(42), int64(7)}
+	d, err = w.Authorize(context.Background(), &Request{Claims: user, Resource: batch})
+	require.NoError(t, err)
+	require.False(t, d.Allow)
+
+	// 管理员可以访问所有资源
+	d, err = w.Authorize(context.Background(), &Request{Claims: user, Roles: []string{"admin"}, Resource: other})
+	require.NoError(t, err)
+	require.True(t, d.Allow)
+	require.Nil(t, d.Filters)
+
+	// 请求者类型不匹配
+	d, err = w.Authorize(context.Background(), &Request{Claims: jwt.MapClaims{"uid": 42, "typ": "admin"}, Resource: order})
+	require.NoError(t, err)
+	require.False(t, d.Allow)
+
+	// 缺少 ID claim
+	d, err = w.Authorize(context.Background(), &Request{Claims: jwt.MapClaims{"sub": "u1", "typ": "user"}, Resource: order})
+	require.NoError(t, err)
+	require.False(t, d.Allow)
+
+	// 非 self-hold 资源不检查
+	d, err = w.Authorize(context.Background(), &Request{Claims: jwt.MapClaims{}, Resource: reqmeta.Resource{ResourceType: "order"}})
+	require.NoError(t, err)
+	require.True(t, d.Allow)
+}
+
+func TestOwnershipCollection(t *testing.T) {
+	rbac, err := LoadRBAC([]byte(testPolicy))
+	require.NoError(t, err)
+	authorizer := All(rbac, NewOwnership(WithAdminRoles("admin")))
+
+	list := reqmeta.Resource{ResourceType: "order", Action: "GET", IsCollection: true, IsSelfHold: true, OwnerType: "user"}
+	ctx := reqmeta.NewContext(context.Background(), list)
+
+	var filters map[string]any
+	handler := Server(authorizer)(func(ctx context.Context, req any) (any, error) {
+		filters = FiltersFrom(ctx)
+		return nil, nil
+	})
+
+	_, err = handler(authjwt.NewContext(ctx, jwt.MapClaims{"sub": "u1", "roles": []string{"user"}}), nil)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"owner_id": "u1"}, filters)
+
+	_, err = handler(authjwt.NewContext(ctx, jwt.MapClaims{"sub": "u2", "roles": []string{"admin"}}), nil)
+	require.NoError(t, err)
+	require.Nil(t, filters)
+
+	// RBAC 先于 ownership 拒绝
+	_, err = handler(authjwt.NewContext(ctx, jwt.MapClaims{"sub": "u1"}), nil)
+	require.Equal(t, ReasonPermissionDenied, errors.Reason(err))
+
+	single := reqmeta.Resource{ResourceType: "order", Action: "GET", IsSelfHold: true, OwnerId: "u2"}
+	ctx = reqmeta.NewContext(context.Background(), single)
+	_, err = handler(authjwt.NewContext(ctx, jwt.MapClaims{"sub": "u1", "roles": "user"}), nil)
+	require.True(t, errors.IsForbidden(err))
+	require.Equal(t, ReasonNotOwner, errors.Reason(err))
+}
@@ -0,0 +1,115 @@
+package authz
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// wildcard 匹配任意资源类型或操作
+const wildcard = "*"
+
+// Permission 对某类资源的一组操作的权限
+type Permission struct {
+	Resource string   `json:"resource" yaml:"resource"`
+	Actions  []string `json:"actions" yaml:"actions"`
+}
+
+// RBACPolicy 角色到权限的映射，可以从 YAML 或者 JSON 加载，例如
+//
+//	roles:
+//	  admin:
+//	    - resource: "*"
+//	      actions: ["*"]
+//	  user:
+//	    - resource: order
+//	      actions: [GET, CREATE]
+type RBACPolicy struct {
+	Roles map[string][]Permission `json:"roles" yaml:"roles"`
+}
+
+// RBAC 基于角色的 Authorizer，请求者的任意一个角色具有资源类型和操作对应的权限时允许请求。
+// 操作不区分大小写
+type RBAC struct {
+	roles map[string][]Permission
+}
+
+var _ Authorizer = (*RBAC)(nil)
+
+// NewRBAC 编译 policy
+func NewRBAC(policy RBACPolicy) (*RBAC, error) {
+	roles := make(map[string][]Permission, len(policy.Roles))
+	for role, perms := range policy.Roles {
+		compiled := make([]Permission, 0, len(perms))
+		for i, p := range perms {
+			if p.Resource == "" {
+				return nil, fmt.Errorf("authz: role %q permission %d: missing resource", role, i)
+			}
+			if len(p.Actions) == 0 {
+				return nil, fmt.Errorf("authz: role %q permission %d: missing actions", role, i)
+			}
+			actions := make([]string, len(p.Actions))
+			for j, a := range p.Actions {
+				actions[j] = strings.ToUpper(a)
+			}
+			compiled = append(compiled, Permission{Resource: p.Resource, Actions: actions})
+		}
+		roles[role] = compiled
+	}
+	return &RBAC{roles: roles}, nil
+}
+
+// LoadRBAC 从 YAML 或者 JSON 加载 RBACPolicy 并编译
+func LoadRBAC(data []byte) (*RBAC, error) {
+	var policy RBACPolicy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("authz: parse rbac policy: %w", err)
+	}
+	return NewRBAC(policy)
+}
+
+// LoadRBACFile 从文件加载 RBACPolicy 并编译
+func LoadRBACFile(path string) (*RBAC, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("authz: read rbac policy: %w", err)
+	}
+	return LoadRBAC(data)
+}
+
+// Allowed 判断 roles 中是否有角色可以对 resourceType 执行 action
+func (r *RBAC) Allowed(roles []string, resourceType, action string) bool {
+	_, ok := r.match(roles, resourceType, action)
+	return ok
+}
+
+// match 返回第一条允许请求的权限，格式为 role:resource
+func (r *RBAC) match(roles []string, resourceType, action string) (string, bool) {
+	action = strings.ToUpper(action)
+	for _, role := range roles {
+		for _, p := range r.roles[role] {
+			if p.Resource != wildcard && p.Resource != resourceType {
+				continue
+			}
+			if slices.Contains(p.Actions, wildcard) || slices.Contains(p.Actions, action) {
+				return role + ":" + p.Resource, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Authorize implements Authorizer.
+func (r *RBAC) Authorize(_ context.Context, req *Request) (Decision, error) {
+	res := req.Resource
+	if rule, ok := r.match(req.Roles, res.ResourceType, res.Action); ok {
+		d := Allow()
+		d.Rule = rule
+		return d, nil
+	}
+	return Deny(ReasonPermissionDenied, "roles %v can not %s %s", req.Roles, res.Action, res.ResourceType), nil
+}
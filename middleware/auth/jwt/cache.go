@@ -0,0 +1,120 @@
+package jwt
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// WithTokenCache 缓存验证过签名的 token，命中缓存时跳过解析和签名验证，
+// 但仍然会验证 claims 并检查是否被作废。
+// 缓存中的 claims 会被多个请求共享，handler 不应当修改它们；
+// 不同配置的 Server 不应当共享同一个 TokenCache
+func WithTokenCache(cache *TokenCache) Option {
+	return func(o *options) {
+		o.cache = cache
+	}
+}
+
+// CacheStats TokenCache 的统计信息
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Size      int
+}
+
+type cacheEntry struct {
+	key       [sha256.Size]byte
+	token     *jwt.Token
+	expiresAt time.Time
+}
+
+// TokenCache 以 token 的哈希为键的 LRU 缓存，token 过期后自动失效，所有方法都是线程安全的
+type TokenCache struct {
+	size int
+
+	mu        sync.Mutex
+	ll        *list.List
+	entries   map[[sha256.Size]byte]*list.Element
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+// NewTokenCache 创建最多缓存 size 个 token 的 TokenCache
+func NewTokenCache(size int) *TokenCache {
+	return &TokenCache{
+		size:    max(size, 1),
+		ll:      list.New(),
+		entries: make(map[[sha256.Size]byte]*list.Element),
+	}
+}
+
+// Stats 返回缓存的统计信息
+func (c *TokenCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Size:      c.ll.Len(),
+	}
+}
+
+// Purge 清空缓存，例如在密钥轮换之后
+func (c *TokenCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ll.Init()
+	clear(c.entries)
+}
+
+func (c *TokenCache) get(tokenString string) (*jwt.Token, bool) {
+	key := sha256.Sum256([]byte(tokenString))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	e := el.Value.(*cacheEntry)
+	if !e.expiresAt.IsZero() && !time.Now().Before(e.expiresAt) {
+		c.removeLocked(el)
+		c.misses++
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	c.hits++
+	return e.token, true
+}
+
+// add 缓存 token 直到 expiresAt，零值表示没有过期时间
+func (c *TokenCache) add(tokenString string, token *jwt.Token, expiresAt time.Time) {
+	key := sha256.Sum256([]byte(tokenString))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.ll.PushFront(&cacheEntry{key: key, token: token, expiresAt: expiresAt})
+	for c.ll.Len() > c.size {
+		c.removeLocked(c.ll.Back())
+		c.evictions++
+	}
+}
+
+func (c *TokenCache) removeLocked(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.entries, el.Value.(*cacheEntry).key)
+}
@@ -0,0 +1,79 @@
+package jwt
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	authjwt "github.com/unkmonster/go-kit/auth/jwt"
+)
+
+func TestTokenCache(t *testing.T) {
+	var verified atomic.Int32
+	cache := NewTokenCache(2)
+	mw := newServer(
+		WithKeyFunc(func(token *jwt.Token) (any, error) {
+			verified.Add(1)
+			return testKey, nil
+		}),
+		WithTokenCache(cache),
+	)
+
+	a := signHS256(t, jwt.RegisteredClaims{Subject: "a", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))})
+	require.NoError(t, serveToken(mw, a))
+	require.NoError(t, serveToken(mw, a))
+	require.EqualValues(t, 1, verified.Load())
+	require.Equal(t, CacheStats{Hits: 1, Misses: 1, Size: 1}, cache.Stats())
+
+	// 超过容量时淘汰最久未使用的 token
+	b := signHS256(t, jwt.RegisteredClaims{Subject: "b"})
+	c := signHS256(t, jwt.RegisteredClaims{Subject: "c"})
+	require.NoError(t, serveToken(mw, b))
+	require.NoError(t, serveToken(mw, c))
+	require.Equal(t, CacheStats{Hits: 1, Misses: 3, Evictions: 1, Size: 2}, cache.Stats())
+	require.NoError(t, serveToken(mw, a))
+	require.EqualValues(t, 4, verified.Load())
+
+	// 验证失败的 token 不会被缓存
+	other, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{}).SignedString([]byte("other"))
+	require.NoError(t, err)
+	requireError(t, ErrTokenInvalid, serveToken(mw, other))
+	requireError(t, ErrTokenInvalid, serveToken(mw, other))
+	require.Equal(t, 2, cache.Stats().Size)
+
+	cache.Purge()
+	require.Zero(t, cache.Stats().Size)
+}
+
+func TestTokenCacheExpiry(t *testing.T) {
+	cache := NewTokenCache(10)
+	mw := newServer(WithKeyFunc(testKeyProvider), WithTokenCache(cache), WithLeeway(time.Second))
+
+	tokenString := signHS256(t, jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Second))})
+	require.NoError(t, serveToken(mw, tokenString))
+	require.NoError(t, serveToken(mw, tokenString))
+	require.EqualValues(t, 1, cache.Stats().Hits)
+
+	// 过期的缓存不再命中，token 本身也已经过期
+	time.Sleep(2100 * time.Millisecond)
+	requireError(t, ErrTokenExpired, serveToken(mw, tokenString))
+	require.EqualValues(t, 1, cache.Stats().Hits)
+	require.Zero(t, cache.Stats().Size)
+}
+
+func TestTokenCacheRevocation(t *testing.T) {
+	store := authjwt.NewMemoryRevocationStore(10)
+	cache := NewTokenCache(10)
+	mw := newServer(WithKeyFunc(testKeyProvider), WithTokenCache(cache), WithRevocationStore(store))
+
+	tokenString := signHS256(t, jwt.RegisteredClaims{ID: "jti", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))})
+	require.NoError(t, serveToken(mw, tokenString))
+
+	// 命中缓存时仍然检查是否被作废
+	require.NoError(t, store.RevokeToken(context.Background(), "jti", time.Now().Add(time.Hour)))
+	require.Equal(t, ErrTokenRevoked, serveToken(mw, tokenString))
+	require.EqualValues(t, 1, cache.Stats().Hits)
+}
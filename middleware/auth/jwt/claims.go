@@ -0,0 +1,94 @@
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ClaimsPointer 约束 *T 实现 jwt.Claims
+type ClaimsPointer[T any] interface {
+	*T
+	jwt.Claims
+}
+
+// WithTypedClaims 使用 *T 作为 claims，每次解析都会创建新的 *T，
+// 之后可以通过 ClaimsFrom[*T] 取出
+func WithTypedClaims[T any, PT ClaimsPointer[T]]() Option {
+	return WithClaims(func() jwt.Claims {
+		return PT(new(T))
+	})
+}
+
+// TypedServer 等同于 Server(append(opts, WithTypedClaims[T]())...)
+func TypedServer[T any, PT ClaimsPointer[T]](opts ...Option) middleware.Middleware {
+	return Server(append(opts, WithTypedClaims[T, PT]())...)
+}
+
+// ClaimsFrom 从 context 中取出类型为 T 的 claims，
+// 使用 TypedServer[MyClaims] 时 T 为 *MyClaims
+func ClaimsFrom[T jwt.Claims](ctx context.Context) (T, bool) {
+	claims, ok := FromContext(ctx)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	t, ok := claims.(T)
+	return t, ok
+}
+
+// SubjectFrom 返回 context 中 claims 的 sub
+func SubjectFrom(ctx context.Context) (string, bool) {
+	claims, ok := FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	sub, err := claims.GetSubject()
+	if err != nil || sub == "" {
+		return "", false
+	}
+	return sub, true
+}
+
+// ScopesFrom 返回 context 中 claims 的 scope，scope 可以是空格分隔的字符串或者字符串数组
+func ScopesFrom(ctx context.Context) []string {
+	return ClaimStringsFrom(ctx, "scope")
+}
+
+// ClaimStringsFrom 返回名为 name 的 claim，值可以是空格分隔的字符串或者字符串数组
+func ClaimStringsFrom(ctx context.Context, name string) []string {
+	v, _ := ClaimFrom[any](ctx, name)
+	return claimStrings(v)
+}
+
+// ClaimFrom 将名为 name 的 claim 解析为 T，适用于任意 claims 类型，
+// claims 中没有该字段或者类型不匹配时返回 false
+func ClaimFrom[T any](ctx context.Context, name string) (T, bool) {
+	var zero T
+	claims, ok := FromContext(ctx)
+	if !ok {
+		return zero, false
+	}
+
+	// 通过 JSON 读取字段，自定义 claims 和 jwt.MapClaims 可以统一处理
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return zero, false
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return zero, false
+	}
+	raw, ok := fields[name]
+	if !ok {
+		return zero, false
+	}
+
+	var v T
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return zero, false
+	}
+	return v, true
+}
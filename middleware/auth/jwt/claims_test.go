@@ -0,0 +1,92 @@
+package jwt
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+type userClaims struct {
+	jwt.RegisteredClaims
+	Scope  string   `json:"scope"`
+	Roles  []string `json:"roles"`
+	Tenant int64    `json:"tenant"`
+}
+
+func TestDefaultClaims(t *testing.T) {
+	var claims jwt.Claims
+	mw := Server(WithKeyFunc(testKeyProvider))(func(ctx context.Context, req any) (any, error) {
+		claims, _ = FromContext(ctx)
+		return nil, nil
+	})
+
+	tokenString := signHS256(t, jwt.RegisteredClaims{Subject: "u1"})
+	require.NoError(t, serveToken(mw, tokenString))
+	registered, ok := claims.(*jwt.RegisteredClaims)
+	require.True(t, ok)
+	require.Equal(t, "u1", registered.Subject)
+
+	// 每次请求使用新的 claims
+	require.NoError(t, serveToken(mw, signHS256(t, jwt.RegisteredClaims{Subject: "u2"})))
+	require.Equal(t, "u1", registered.Subject)
+}
+
+func TestTypedServer(t *testing.T) {
+	var (
+		claims *userClaims
+		ok     bool
+		ctx    context.Context
+	)
+	mw := TypedServer[userClaims](WithKeyFunc(testKeyProvider))(func(c context.Context, req any) (any, error) {
+		ctx = c
+		claims, ok = ClaimsFrom[*userClaims](c)
+		return nil, nil
+	})
+
+	tokenString := signHS256(t, userClaims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: "u1"},
+		Scope:            "read write",
+		Roles:            []string{"admin"},
+		Tenant:           42,
+	})
+	require.NoError(t, serveToken(mw, tokenString))
+	require.True(t, ok)
+	require.Equal(t, "u1", claims.Subject)
+	require.EqualValues(t, 42, claims.Tenant)
+
+	_, ok = ClaimsFrom[*jwt.RegisteredClaims](ctx)
+	require.False(t, ok)
+
+	sub, ok := SubjectFrom(ctx)
+	require.True(t, ok)
+	require.Equal(t, "u1", sub)
+	require.Equal(t, []string{"read", "write"}, ScopesFrom(ctx))
+	require.Equal(t, []string{"admin"}, ClaimStringsFrom(ctx, "roles"))
+
+	tenant, ok := ClaimFrom[int64](ctx, "tenant")
+	require.True(t, ok)
+	require.EqualValues(t, 42, tenant)
+	_, ok = ClaimFrom[int64](ctx, "scope")
+	require.False(t, ok)
+	_, ok = ClaimFrom[string](ctx, "missing")
+	require.False(t, ok)
+}
+
+func TestClaimHelpersWithMapClaims(t *testing.T) {
+	ctx := NewContext(context.Background(), jwt.MapClaims{"sub": "u1", "scope": []any{"a", "b"}, "org": "acme"})
+
+	sub, ok := SubjectFrom(ctx)
+	require.True(t, ok)
+	require.Equal(t, "u1", sub)
+	require.Equal(t, []string{"a", "b"}, ScopesFrom(ctx))
+	org, ok := ClaimFrom[string](ctx, "org")
+	require.True(t, ok)
+	require.Equal(t, "acme", org)
+
+	_, ok = SubjectFrom(context.Background())
+	require.False(t, ok)
+	require.Empty(t, ScopesFrom(transport.NewServerContext(context.Background(), newTestTransport())))
+}
@@ -0,0 +1,137 @@
+package jwt
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// defaultRefreshBefore 缓存的 token 在过期前多久重新签发
+const defaultRefreshBefore = 30 * time.Second
+
+// WithSigningMethod 指定 Client 签发 token 使用的算法，默认为 HS256
+func WithSigningMethod(method jwt.SigningMethod) Option {
+	return func(o *options) {
+		o.signingMethod = method
+	}
+}
+
+// WithTokenHeader 为 Client 签发的 token 添加额外的 header，例如 kid
+func WithTokenHeader(header map[string]any) Option {
+	return func(o *options) {
+		o.tokenHeader = header
+	}
+}
+
+// WithForwardToken 指示 Client 优先转发服务端收到的 Authorization header，
+// 没有可转发的 token 时才使用 keyProvider 签发
+func WithForwardToken(forward bool) Option {
+	return func(o *options) {
+		o.forward = forward
+	}
+}
+
+// WithRefreshBefore 指定 Client 缓存的 token 在过期前多久重新签发，默认为 30 秒，
+// d < 0 表示不缓存，每次调用都签发新的 token。没有 exp 的 token 不会被缓存
+func WithRefreshBefore(d time.Duration) Option {
+	return func(o *options) {
+		o.refreshBefore = d
+	}
+}
+
+// Client 客户端中间件，为请求设置 Authorization header。
+// keyProvider 返回签名使用的密钥，token 的 claims 来自 WithClaims，
+// 签发的 token 会被缓存到过期前 WithRefreshBefore 为止
+func Client(keyProvider jwt.Keyfunc, opts ...Option) middleware.Middleware {
+	o := &options{
+		claims:        newRegisteredClaims,
+		signingMethod: jwt.SigningMethodHS256,
+		refreshBefore: defaultRefreshBefore,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	cache := &tokenCache{}
+
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req any) (any, error) {
+			tr, ok := transport.FromClientContext(ctx)
+			if !ok {
+				return nil, ErrWrongContext
+			}
+
+			if o.forward {
+				if str, ok := transport.FromServerContext(ctx); ok {
+					if value := str.RequestHeader().Get(authorizationKey); value != "" {
+						tr.RequestHeader().Set(authorizationKey, value)
+						return handler(ctx, req)
+					}
+				}
+			}
+
+			if keyProvider == nil {
+				return nil, ErrNeedTokenProvider
+			}
+
+			tokenString, err := cache.get(o, keyProvider)
+			if err != nil {
+				return nil, err
+			}
+			tr.RequestHeader().Set(authorizationKey, bearerWord+" "+tokenString)
+			return handler(ctx, req)
+		}
+	}
+}
+
+// tokenCache 缓存 Client 最近一次签发的 token
+type tokenCache struct {
+	mu        sync.Mutex
+	token     string
+	refreshAt time.Time
+}
+
+func (c *tokenCache) get(o *options, keyProvider jwt.Keyfunc) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && time.Now().Before(c.refreshAt) {
+		return c.token, nil
+	}
+
+	claims := o.claims()
+	tokenString, err := signToken(o, keyProvider, claims)
+	if err != nil {
+		return "", err
+	}
+
+	c.token = ""
+	if o.refreshBefore >= 0 {
+		if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+			c.token = tokenString
+			c.refreshAt = exp.Add(-o.refreshBefore)
+		}
+	}
+	return tokenString, nil
+}
+
+func signToken(o *options, keyProvider jwt.Keyfunc, claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(o.signingMethod, claims)
+	for k, v := range o.tokenHeader {
+		token.Header[k] = v
+	}
+
+	key, err := keyProvider(token)
+	if err != nil {
+		return "", ErrGetKey.WithCause(err)
+	}
+	tokenString, err := token.SignedString(key)
+	if err != nil {
+		return "", ErrSignToken.WithCause(err)
+	}
+	return tokenString, nil
+}
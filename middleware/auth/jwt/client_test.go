@@ -0,0 +1,197 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+type headerCarrier http.Header
+
+func (hc headerCarrier) Get(key string) string { return http.Header(hc).Get(key) }
+
+func (hc headerCarrier) Set(key string, value string) { http.Header(hc).Set(key, value) }
+
+func (hc headerCarrier) Add(key string, value string) { http.Header(hc).Add(key, value) }
+
+func (hc headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(hc))
+	for k := range http.Header(hc) {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func (hc headerCarrier) Values(key string) []string { return http.Header(hc).Values(key) }
+
+type testTransport struct {
+	kind      transport.Kind
+	operation string
+	header    headerCarrier
+}
+
+func newTestTransport() *testTransport {
+	return &testTransport{kind: transport.KindGRPC, operation: "/test.Service/Call", header: headerCarrier{}}
+}
+
+func (tr *testTransport) Kind() transport.Kind            { return tr.kind }
+func (tr *testTransport) Endpoint() string                { return "" }
+func (tr *testTransport) Operation() string               { return tr.operation }
+func (tr *testTransport) RequestHeader() transport.Header { return tr.header }
+func (tr *testTransport) ReplyHeader() transport.Header   { return headerCarrier{} }
+
+var testKey = []byte("test-signing-key")
+
+func testKeyProvider(*jwt.Token) (any, error) {
+	return testKey, nil
+}
+
+func callClient(t *testing.T, ctx context.Context, m func(ctx context.Context) (*testTransport, error)) string {
+	t.Helper()
+	tr, err := m(ctx)
+	require.NoError(t, err)
+	return tr.header.Get(authorizationKey)
+}
+
+func runClient(keyProvider jwt.Keyfunc, opts ...Option) func(ctx context.Context) (*testTransport, error) {
+	mw := Client(keyProvider, opts...)
+	return func(ctx context.Context) (*testTransport, error) {
+		tr := newTestTransport()
+		ctx = transport.NewClientContext(ctx, tr)
+		_, err := mw(func(ctx context.Context, req any) (any, error) {
+			return nil, nil
+		})(ctx, nil)
+		return tr, err
+	}
+}
+
+func TestClientSignToken(t *testing.T) {
+	call := runClient(testKeyProvider,
+		WithClaims(func() jwt.Claims {
+			return jwt.RegisteredClaims{
+				Subject:   "svc",
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			}
+		}),
+		WithTokenHeader(map[string]any{"kid": "k1"}),
+	)
+
+	value := callClient(t, context.Background(), call)
+	require.True(t, strings.HasPrefix(value, bearerWord+" "))
+
+	claims := &jwt.RegisteredClaims{}
+	token, err := jwt.ParseWithClaims(strings.TrimPrefix(value, bearerWord+" "), claims, testKeyProvider)
+	require.NoError(t, err)
+	require.True(t, token.Valid)
+	require.Equal(t, "svc", claims.Subject)
+	require.Equal(t, "k1", token.Header["kid"])
+	require.Equal(t, jwt.SigningMethodHS256.Alg(), token.Method.Alg())
+}
+
+func TestClientCache(t *testing.T) {
+	var signed atomic.Int32
+	keyProvider := func(*jwt.Token) (any, error) {
+		signed.Add(1)
+		return testKey, nil
+	}
+	exp := time.Now().Add(time.Hour)
+	claims := func() jwt.Claims {
+		return jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(exp)}
+	}
+
+	t.Run("cached", func(t *testing.T) {
+		signed.Store(0)
+		call := runClient(keyProvider, WithClaims(claims))
+		first := callClient(t, context.Background(), call)
+		second := callClient(t, context.Background(), call)
+		require.Equal(t, first, second)
+		require.EqualValues(t, 1, signed.Load())
+	})
+
+	t.Run("refresh before expiry", func(t *testing.T) {
+		signed.Store(0)
+		call := runClient(keyProvider, WithClaims(claims), WithRefreshBefore(2*time.Hour))
+		callClient(t, context.Background(), call)
+		callClient(t, context.Background(), call)
+		require.EqualValues(t, 2, signed.Load())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		signed.Store(0)
+		call := runClient(keyProvider, WithClaims(claims), WithRefreshBefore(-1))
+		callClient(t, context.Background(), call)
+		callClient(t, context.Background(), call)
+		require.EqualValues(t, 2, signed.Load())
+	})
+
+	t.Run("no expiry", func(t *testing.T) {
+		signed.Store(0)
+		call := runClient(keyProvider)
+		callClient(t, context.Background(), call)
+		callClient(t, context.Background(), call)
+		require.EqualValues(t, 2, signed.Load())
+	})
+}
+
+func TestClientForwardToken(t *testing.T) {
+	server := newTestTransport()
+	server.header.Set(authorizationKey, "Bearer inbound")
+	ctx := transport.NewServerContext(context.Background(), server)
+
+	t.Run("forward", func(t *testing.T) {
+		call := runClient(nil, WithForwardToken(true))
+		require.Equal(t, "Bearer inbound", callClient(t, ctx, call))
+	})
+
+	t.Run("fallback to sign", func(t *testing.T) {
+		call := runClient(testKeyProvider, WithForwardToken(true))
+		value := callClient(t, context.Background(), call)
+		require.NotEqual(t, "Bearer inbound", value)
+		require.True(t, strings.HasPrefix(value, bearerWord+" "))
+	})
+
+	t.Run("forward disabled", func(t *testing.T) {
+		call := runClient(testKeyProvider)
+		require.NotEqual(t, "Bearer inbound", callClient(t, ctx, call))
+	})
+}
+
+func TestClientErrors(t *testing.T) {
+	t.Run("wrong context", func(t *testing.T) {
+		_, err := Client(testKeyProvider)(func(ctx context.Context, req any) (any, error) {
+			return nil, nil
+		})(context.Background(), nil)
+		require.Equal(t, ErrWrongContext, err)
+	})
+
+	t.Run("missing provider", func(t *testing.T) {
+		_, err := runClient(nil)(context.Background())
+		require.Equal(t, ErrNeedTokenProvider, err)
+	})
+
+	t.Run("get key", func(t *testing.T) {
+		keyErr := errors.New("no key")
+		_, err := runClient(func(*jwt.Token) (any, error) {
+			return nil, keyErr
+		})(context.Background())
+		require.ErrorIs(t, err, keyErr)
+		require.Equal(t, ErrGetKey.Message, kerrors.FromError(err).Message)
+	})
+
+	t.Run("sign", func(t *testing.T) {
+		// HS256 需要 []byte 类型的密钥
+		_, err := runClient(func(*jwt.Token) (any, error) {
+			return "not bytes", nil
+		})(context.Background())
+		require.Equal(t, ErrSignToken.Message, kerrors.FromError(err).Message)
+	})
+}
@@ -0,0 +1,82 @@
+package jwt
+
+import (
+	"context"
+	nethttp "net/http"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/go-kratos/kratos/v2/transport/http"
+)
+
+// Extractor 从请求中提取 token，没有找到时返回空字符串
+type Extractor func(ctx context.Context, tr transport.Transporter) string
+
+// WithExtractors 指定提取 token 的顺序，使用第一个非空的结果，
+// 默认只读取 Authorization: Bearer header
+func WithExtractors(extractors ...Extractor) Option {
+	return func(o *options) {
+		o.extractors = extractors
+	}
+}
+
+// FromAuthorizationHeader 从 Authorization: Bearer header 中提取 token
+func FromAuthorizationHeader() Extractor {
+	return FromHeader(authorizationKey, bearerWord)
+}
+
+// FromHeader 从 header 中提取 token，scheme 不为空时 header 的值必须以 scheme 开头（忽略大小写）。
+// gRPC 请求的 header 即 metadata，因此也可以用来读取 gRPC metadata
+func FromHeader(key, scheme string) Extractor {
+	return func(ctx context.Context, tr transport.Transporter) string {
+		value := tr.RequestHeader().Get(key)
+		if scheme == "" || value == "" {
+			return value
+		}
+		auths := strings.SplitN(value, " ", 2)
+		if len(auths) != 2 || !strings.EqualFold(auths[0], scheme) {
+			return ""
+		}
+		return auths[1]
+	}
+}
+
+// FromCookie 从名为 name 的 cookie 中提取 token
+func FromCookie(name string) Extractor {
+	return func(ctx context.Context, tr transport.Transporter) string {
+		for _, line := range tr.RequestHeader().Values("Cookie") {
+			cookies, err := nethttp.ParseCookie(line)
+			if err != nil {
+				continue
+			}
+			for _, c := range cookies {
+				if c.Name == name {
+					return c.Value
+				}
+			}
+		}
+		return ""
+	}
+}
+
+// FromQuery 从 HTTP 请求的 query 参数中提取 token，例如 WebSocket 握手请求，
+// 其他类型的请求返回空字符串
+func FromQuery(name string) Extractor {
+	return func(ctx context.Context, tr transport.Transporter) string {
+		ht, ok := tr.(http.Transporter)
+		if !ok {
+			return ""
+		}
+		return ht.Request().URL.Query().Get(name)
+	}
+}
+
+// extractToken 依次调用 extractors，返回第一个非空的 token
+func (o *options) extractToken(ctx context.Context, tr transport.Transporter) string {
+	for _, extract := range o.extractors {
+		if token := extract(ctx, tr); token != "" {
+			return token
+		}
+	}
+	return ""
+}
@@ -0,0 +1,74 @@
+package jwt
+
+import (
+	"context"
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/metadata"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+type testHTTPTransport struct {
+	*testTransport
+	req *nethttp.Request
+}
+
+func (tr *testHTTPTransport) Request() *nethttp.Request { return tr.req }
+func (tr *testHTTPTransport) PathTemplate() string      { return tr.req.URL.Path }
+
+func TestExtractors(t *testing.T) {
+	req := httptest.NewRequest(nethttp.MethodGet, "/ws?access_token=query", nil)
+	tr := &testHTTPTransport{testTransport: newTestTransport(), req: req}
+	tr.kind = transport.KindHTTP
+	ctx := context.Background()
+
+	require.Empty(t, FromAuthorizationHeader()(ctx, tr))
+	require.Equal(t, "query", FromQuery("access_token")(ctx, tr))
+	require.Empty(t, FromQuery("access_token")(ctx, newTestTransport()))
+
+	tr.header.Set("Authorization", "bearer header")
+	require.Equal(t, "header", FromAuthorizationHeader()(ctx, tr))
+	tr.header.Set("Authorization", "Basic xxx")
+	require.Empty(t, FromAuthorizationHeader()(ctx, tr))
+
+	tr.header.Set("X-Api-Token", "custom")
+	require.Equal(t, "custom", FromHeader("X-Api-Token", "")(ctx, tr))
+
+	tr.header.Add("Cookie", "theme=dark; session=cookie")
+	require.Equal(t, "cookie", FromCookie("session")(ctx, tr))
+	require.Empty(t, FromCookie("missing")(ctx, tr))
+}
+
+func TestServerExtractorOrder(t *testing.T) {
+	tokenString := signHS256(t, jwt.RegisteredClaims{Subject: "u1"})
+
+	var propagated string
+	mw := Server(
+		WithKeyFunc(testKeyProvider),
+		WithExtractors(FromAuthorizationHeader(), FromCookie("session"), func(ctx context.Context, tr transport.Transporter) string {
+			return tr.RequestHeader().Get("X-Custom")
+		}),
+	)(func(ctx context.Context, req any) (any, error) {
+		md, _ := metadata.FromClientContext(ctx)
+		propagated = md.Get(authorizationKey)
+		return nil, nil
+	})
+
+	call := func(key, value string) error {
+		tr := newTestTransport()
+		tr.header.Set(key, value)
+		_, err := mw(transport.NewServerContext(context.Background(), tr), nil)
+		return err
+	}
+
+	require.NoError(t, call("Cookie", "session="+tokenString))
+	// token 统一以 Authorization header 的形式传播
+	require.Equal(t, bearerWord+" "+tokenString, propagated)
+
+	require.NoError(t, call("X-Custom", tokenString))
+	requireError(t, ErrMissingJwtToken, call("X-Other", tokenString))
+}
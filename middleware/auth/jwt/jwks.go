@@ -0,0 +1,224 @@
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	authjwt "github.com/unkmonster/go-kit/auth/jwt"
+)
+
+// ErrKeyNotFound JWKS 中没有与 token 的 kid 和 alg 匹配的公钥
+var ErrKeyNotFound = errors.New("jwks: no matching key")
+
+type jwksOptions struct {
+	client             *http.Client
+	ttl                time.Duration
+	minRefreshInterval time.Duration
+}
+
+// JWKSOption 配置 JWKS
+type JWKSOption func(o *jwksOptions)
+
+// WithJWKSClient 指定拉取 JWKS 使用的 http.Client，默认为 http.DefaultClient
+func WithJWKSClient(client *http.Client) JWKSOption {
+	return func(o *jwksOptions) {
+		o.client = client
+	}
+}
+
+// WithJWKSCacheTTL 指定 JWKS 的缓存时间，默认为 10 分钟
+func WithJWKSCacheTTL(ttl time.Duration) JWKSOption {
+	return func(o *jwksOptions) {
+		o.ttl = ttl
+	}
+}
+
+// WithJWKSMinRefreshInterval 指定两次拉取 JWKS 的最小间隔，默认为 30 秒，
+// 用于限制遇到未知 kid 时的刷新频率
+func WithJWKSMinRefreshInterval(d time.Duration) JWKSOption {
+	return func(o *jwksOptions) {
+		o.minRefreshInterval = d
+	}
+}
+
+// JWKS 从 JWKS URL 获取公钥用于验证 token，所有方法都是线程安全的。
+// 公钥会被缓存，过期或者遇到未知 kid 时重新拉取
+type JWKS struct {
+	url string
+	o   *jwksOptions
+
+	// 保证同一时刻只有一个拉取请求
+	refreshMu sync.Mutex
+
+	mu          sync.RWMutex
+	keys        []jwksKey
+	fetchedAt   time.Time
+	lastAttempt time.Time
+}
+
+type jwksKey struct {
+	jwk authjwt.JWK
+	key any
+}
+
+// NewJWKS 创建从 url 获取公钥的 JWKS，第一次验证 token 时才会拉取
+func NewJWKS(url string, opts ...JWKSOption) *JWKS {
+	o := &jwksOptions{
+		client:             http.DefaultClient,
+		ttl:                10 * time.Minute,
+		minRefreshInterval: 30 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &JWKS{url: url, o: o}
+}
+
+// KeyFunc 根据 token 的 kid 和 alg 选择公钥，可以直接传给 WithKeyFunc2。
+// token 没有 kid 时返回所有与 alg 兼容的公钥
+func (j *JWKS) KeyFunc(ctx context.Context, token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	alg := token.Method.Alg()
+
+	if j.expired() {
+		// 拉取失败时继续使用旧的公钥
+		if err := j.refresh(ctx, j.expired); err != nil && !j.loaded() {
+			return nil, err
+		}
+	}
+
+	key, err := j.lookup(kid, alg)
+	if errors.Is(err, ErrKeyNotFound) && kid != "" {
+		// 遇到未知 kid 时可能是密钥已经轮换
+		if err := j.refresh(ctx, j.refreshAllowed); err != nil {
+			return nil, err
+		}
+		key, err = j.lookup(kid, alg)
+	}
+	return key, err
+}
+
+// Refresh 立即拉取 JWKS
+func (j *JWKS) Refresh(ctx context.Context) error {
+	return j.refresh(ctx, func() bool { return true })
+}
+
+// refresh 在 need 返回 true 时拉取 JWKS，need 在持有 refreshMu 后判断，
+// 这样并发等待的调用者不会重复拉取
+func (j *JWKS) refresh(ctx context.Context, need func() bool) error {
+	j.refreshMu.Lock()
+	defer j.refreshMu.Unlock()
+
+	if !need() {
+		return nil
+	}
+
+	j.mu.Lock()
+	j.lastAttempt = time.Now()
+	j.mu.Unlock()
+
+	keys, err := j.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	j.keys = keys
+	j.fetchedAt = time.Now()
+	j.mu.Unlock()
+	return nil
+}
+
+func (j *JWKS) fetch(ctx context.Context) ([]jwksKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: new request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := j.o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: fetch %s: %w", j.url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks: fetch %s: unexpected status %s", j.url, resp.Status)
+	}
+
+	var set authjwt.JWKSet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("jwks: decode: %w", err)
+	}
+
+	keys := make([]jwksKey, 0, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		// 忽略不支持的公钥，不影响其他公钥的使用
+		pub, err := k.PublicKey()
+		if err != nil {
+			continue
+		}
+		keys = append(keys, jwksKey{jwk: k, key: pub})
+	}
+	return keys, nil
+}
+
+func (j *JWKS) lookup(kid, alg string) (any, error) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	if kid != "" {
+		for _, k := range j.keys {
+			if k.jwk.Kid == kid {
+				if !k.jwk.SupportsAlg(alg) {
+					return nil, fmt.Errorf("jwks: key %q does not support %s", kid, alg)
+				}
+				return k.key, nil
+			}
+		}
+		return nil, ErrKeyNotFound
+	}
+
+	var set jwt.VerificationKeySet
+	for _, k := range j.keys {
+		if k.jwk.SupportsAlg(alg) {
+			set.Keys = append(set.Keys, k.key)
+		}
+	}
+	if len(set.Keys) == 0 {
+		return nil, ErrKeyNotFound
+	}
+	return set, nil
+}
+
+func (j *JWKS) loaded() bool {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return !j.fetchedAt.IsZero()
+}
+
+func (j *JWKS) refreshAllowed() bool {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.refreshAllowedLocked()
+}
+
+func (j *JWKS) refreshAllowedLocked() bool {
+	return j.lastAttempt.IsZero() || time.Since(j.lastAttempt) >= j.o.minRefreshInterval
+}
+
+func (j *JWKS) expired() bool {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	stale := j.fetchedAt.IsZero() || time.Since(j.fetchedAt) >= j.o.ttl
+	return stale && j.refreshAllowedLocked()
+}
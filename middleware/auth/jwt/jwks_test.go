@@ -0,0 +1,205 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/transport"
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	authjwt "github.com/unkmonster/go-kit/auth/jwt"
+)
+
+type jwksServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	keys     []authjwt.JWK
+	requests atomic.Int32
+}
+
+func newJWKSServer(t *testing.T, keys ...authjwt.JWK) *jwksServer {
+	s := &jwksServer{keys: keys}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(authjwt.JWKSet{Keys: s.keys})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) setKeys(keys ...authjwt.JWK) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func mustJWK(kid string, pub any) authjwt.JWK {
+	k, err := authjwt.NewJWK(kid, "", pub)
+	if err != nil {
+		panic(err)
+	}
+	return k
+}
+
+func rsaJWK(kid string, pub *rsa.PublicKey) authjwt.JWK { return mustJWK(kid, pub) }
+
+func ecJWK(kid string, pub *ecdsa.PublicKey) authjwt.JWK { return mustJWK(kid, pub) }
+
+func edJWK(kid string, pub ed25519.PublicKey) authjwt.JWK { return mustJWK(kid, pub) }
+
+func signWithKid(t *testing.T, method jwt.SigningMethod, kid string, key any) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, jwt.RegisteredClaims{Subject: "user"})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(key)
+	require.NoError(t, err)
+	return s
+}
+
+func verify(j *JWKS, tokenString string) error {
+	_, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
+		return j.KeyFunc(context.Background(), token)
+	})
+	return err
+}
+
+func TestJWKSKeyTypes(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	srv := newJWKSServer(t, rsaJWK("rsa", &rsaKey.PublicKey), ecJWK("ec", &ecKey.PublicKey), edJWK("ed", edPub))
+	j := NewJWKS(srv.URL)
+
+	require.NoError(t, verify(j, signWithKid(t, jwt.SigningMethodRS256, "rsa", rsaKey)))
+	require.NoError(t, verify(j, signWithKid(t, jwt.SigningMethodPS256, "rsa", rsaKey)))
+	require.NoError(t, verify(j, signWithKid(t, jwt.SigningMethodES256, "ec", ecKey)))
+	require.NoError(t, verify(j, signWithKid(t, jwt.SigningMethodEdDSA, "ed", edPriv)))
+
+	// 没有 kid 时尝试所有兼容的公钥
+	require.NoError(t, verify(j, signWithKid(t, jwt.SigningMethodES256, "", ecKey)))
+
+	// kid 与 alg 不匹配
+	require.Error(t, verify(j, signWithKid(t, jwt.SigningMethodES256, "rsa", ecKey)))
+	require.EqualValues(t, 1, srv.requests.Load())
+}
+
+func TestJWKSAlgPinned(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	k := rsaJWK("rsa", &rsaKey.PublicKey)
+	k.Alg = "RS256"
+	j := NewJWKS(newJWKSServer(t, k).URL)
+
+	require.NoError(t, verify(j, signWithKid(t, jwt.SigningMethodRS256, "rsa", rsaKey)))
+	require.Error(t, verify(j, signWithKid(t, jwt.SigningMethodRS384, "rsa", rsaKey)))
+}
+
+func TestJWKSRotation(t *testing.T) {
+	oldKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	newKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	srv := newJWKSServer(t, ecJWK("old", &oldKey.PublicKey))
+	j := NewJWKS(srv.URL, WithJWKSMinRefreshInterval(time.Hour))
+
+	require.NoError(t, verify(j, signWithKid(t, jwt.SigningMethodES256, "old", oldKey)))
+	require.EqualValues(t, 1, srv.requests.Load())
+
+	// 未知 kid 触发刷新，但受最小间隔限制
+	srv.setKeys(ecJWK("old", &oldKey.PublicKey), ecJWK("new", &newKey.PublicKey))
+	err = verify(j, signWithKid(t, jwt.SigningMethodES256, "new", newKey))
+	require.ErrorIs(t, err, ErrKeyNotFound)
+	require.EqualValues(t, 1, srv.requests.Load())
+
+	j.o.minRefreshInterval = 0
+	require.NoError(t, verify(j, signWithKid(t, jwt.SigningMethodES256, "new", newKey)))
+	require.EqualValues(t, 2, srv.requests.Load())
+
+	// 已知 kid 不会触发刷新
+	require.NoError(t, verify(j, signWithKid(t, jwt.SigningMethodES256, "old", oldKey)))
+	require.EqualValues(t, 2, srv.requests.Load())
+}
+
+func TestJWKSCacheTTL(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	srv := newJWKSServer(t, ecJWK("k", &key.PublicKey))
+	j := NewJWKS(srv.URL, WithJWKSCacheTTL(0), WithJWKSMinRefreshInterval(0))
+
+	tokenString := signWithKid(t, jwt.SigningMethodES256, "k", key)
+	require.NoError(t, verify(j, tokenString))
+	require.NoError(t, verify(j, tokenString))
+	require.EqualValues(t, 2, srv.requests.Load())
+
+	// 拉取失败时继续使用缓存的公钥
+	srv.Close()
+	require.NoError(t, verify(j, tokenString))
+}
+
+func TestJWKSServerMiddleware(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	j := NewJWKS(newJWKSServer(t, ecJWK("k", &key.PublicKey)).URL)
+
+	mw := Server(
+		WithKeyFunc2(j.KeyFunc),
+	)(func(ctx context.Context, req any) (any, error) {
+		claims, ok := FromContext(ctx)
+		require.True(t, ok)
+		return claims.GetSubject()
+	})
+
+	tr := newTestTransport()
+	tr.header.Set(authorizationKey, bearerWord+" "+signWithKid(t, jwt.SigningMethodES256, "k", key))
+	reply, err := mw(transport.NewServerContext(context.Background(), tr), nil)
+	require.NoError(t, err)
+	require.Equal(t, "user", reply)
+
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tr.header.Set(authorizationKey, bearerWord+" "+signWithKid(t, jwt.SigningMethodES256, "k", other))
+	_, err = mw(transport.NewServerContext(context.Background(), tr), nil)
+	require.Error(t, err)
+}
+
+func TestJWKSFromKeyRing(t *testing.T) {
+	ring := authjwt.NewKeyRing(authjwt.WithKeyRingMethod("ES256"))
+	key, err := ring.Rotate()
+	require.NoError(t, err)
+
+	srv := khttp.NewServer()
+	authjwt.RegisterJWKSHandler(srv, ring)
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+
+	j := NewJWKS(ts.URL+authjwt.JWKSPath, WithJWKSMinRefreshInterval(0))
+	require.NoError(t, verify(j, signWithKid(t, key.Method, key.Kid, key.Key)))
+
+	// 轮换后新的 kid 触发刷新
+	rotated, err := ring.Rotate()
+	require.NoError(t, err)
+	require.NoError(t, verify(j, signWithKid(t, rotated.Method, rotated.Kid, rotated.Key)))
+	require.NoError(t, verify(j, signWithKid(t, key.Method, key.Kid, key.Key)))
+}
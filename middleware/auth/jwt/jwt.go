@@ -2,14 +2,14 @@ package jwt
 
 import (
 	"context"
-	"fmt"
-	"strings"
+	"time"
 
 	"github.com/go-kratos/kratos/v2/errors"
 	"github.com/go-kratos/kratos/v2/metadata"
 	"github.com/go-kratos/kratos/v2/middleware"
 	"github.com/go-kratos/kratos/v2/transport"
 	"github.com/golang-jwt/jwt/v5"
+	authjwt "github.com/unkmonster/go-kit/auth/jwt"
 )
 
 type Claims = jwt.Claims
@@ -50,10 +50,39 @@ type options struct {
 	keyFunc  jwt.Keyfunc
 	prevent  bool
 	keyFunc2 KeyFunc
+	// 用于检查 token 是否被作废
+	revocation authjwt.RevocationStore
+	// 以下选项用于验证 claims，未验证签名时同样生效
+	issuers        []string
+	audiences      []string
+	leeway         time.Duration
+	requiredClaims []string
+	signingMethods []string
+	// 提取 token 的顺序
+	extractors []Extractor
+	// 按 operation 匹配的认证规则
+	rules      []*rule
+	scopeClaim string
+	roleClaim  string
+	// 缓存验证过签名的 token
+	cache *TokenCache
+
+	// 以下选项仅用于 Client
+	signingMethod jwt.SigningMethod
+	tokenHeader   map[string]any
+	forward       bool
+	refreshBefore time.Duration
 }
 
 type Option func(*options)
 
+// newRegisteredClaims 默认的 claims，每次解析都需要新的指针
+func newRegisteredClaims() jwt.Claims {
+	return &jwt.RegisteredClaims{}
+}
+
+// WithClaims 指定创建 claims 的函数，每次调用都应当返回新的指针，
+// 使用自定义的 claims 类型时推荐使用 WithTypedClaims
 func WithClaims(f func() jwt.Claims) Option {
 	return func(o *options) {
 		o.claims = f
@@ -82,10 +111,12 @@ func WithPreventReq(prevent bool) Option {
 }
 
 func Server(opts ...Option) middleware.Middleware {
-	claims := jwt.RegisteredClaims{}
 	o := &options{
-		claims:  func() jwt.Claims { return claims },
-		prevent: true,
+		claims:     newRegisteredClaims,
+		prevent:    true,
+		extractors: []Extractor{FromAuthorizationHeader()},
+		scopeClaim: "scope",
+		roleClaim:  "roles",
 	}
 	for _, opt := range opts {
 		opt(o)
@@ -95,68 +126,123 @@ func Server(opts ...Option) middleware.Middleware {
 
 	return func(handler middleware.Handler) middleware.Handler {
 		return func(ctx context.Context, req any) (any, error) {
-			if tr, ok := transport.FromServerContext(ctx); ok {
-				authorizationValue := tr.RequestHeader().Get(authorizationKey)
-
-				if authorizationValue == "" {
-					if !o.prevent {
-						return handler(ctx, req)
-					}
-					return nil, ErrMissingJwtToken
-				}
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return nil, ErrWrongContext
+			}
 
-				// 通过 metadata 传播 authorization header
-				ctx = metadata.AppendToClientContext(ctx, authorizationKey, authorizationValue)
+			r := o.matchRule(tr.Operation())
+			if r != nil && r.mode == AuthSkip {
+				return handler(ctx, req)
+			}
+			// 没有匹配的规则时由 WithPreventReq 决定
+			prevent, optional := o.prevent, !o.prevent
+			if r != nil {
+				prevent, optional = true, r.mode == AuthOptional
+			}
 
-				// 判断是否为 Bearer token
-				auths := strings.SplitN(authorizationValue, " ", 2)
-				if len(auths) != 2 || !strings.EqualFold(auths[0], bearerWord) {
-					if !o.prevent {
-						return handler(ctx, req)
-					}
-					return nil, ErrMissingJwtToken
+			tokenString := o.extractToken(ctx, tr)
+			if tokenString == "" {
+				if optional {
+					return handler(ctx, req)
 				}
+				return nil, ErrMissingJwtToken
+			}
 
-				// 解析 token string
-				tokenString := auths[1]
-
-				var tokenInfo *jwt.Token
-				var err error
-
-				if mustVerify {
-					var keyFunc jwt.Keyfunc
-					if o.keyFunc2 != nil {
-						keyFunc = func(t *jwt.Token) (interface{}, error) {
-							return o.keyFunc2(ctx, t)
-						}
-					} else if o.keyFunc != nil {
-						keyFunc = o.keyFunc
-					}
-					tokenInfo, err = jwt.ParseWithClaims(tokenString, o.claims(), keyFunc)
-				} else {
-					tokenInfo, _, err = jwt.NewParser().ParseUnverified(tokenString, o.claims())
-				}
-				if err != nil {
-					if !o.prevent {
-						return handler(ctx, req)
-					}
-					return nil, errors.Unauthorized(reason, fmt.Sprintf("parse token: %s", err))
+			// 通过 metadata 传播 authorization header，无论 token 来自哪里
+			ctx = metadata.AppendToClientContext(ctx, authorizationKey, bearerWord+" "+tokenString)
+
+			tokenInfo, err := o.parseToken(ctx, tokenString, mustVerify)
+			if err != nil {
+				if !prevent {
+					return handler(ctx, req)
 				}
+				return nil, err
+			}
 
-				if mustVerify && !tokenInfo.Valid {
-					if !o.prevent {
-						return handler(ctx, req)
-					}
-					return nil, ErrTokenInvalid
+			if r != nil {
+				if err := r.authorize(o, tokenInfo); err != nil {
+					return nil, err
 				}
+			}
 
-				// 存入上下文
-				ctx = NewContext(ctx, tokenInfo.Claims)
-				return handler(ctx, req)
+			// 存入上下文
+			ctx = NewContext(ctx, tokenInfo.Claims)
+			return handler(ctx, req)
+		}
+	}
+}
+
+// parseToken 解析 token 并验证签名、claims 以及是否被作废
+func (o *options) parseToken(ctx context.Context, tokenString string, mustVerify bool) (*jwt.Token, error) {
+	var tokenInfo *jwt.Token
+	var cached bool
+	if o.cache != nil {
+		tokenInfo, cached = o.cache.get(tokenString)
+	}
+	if !cached {
+		var err error
+		if tokenInfo, err = o.verifyToken(ctx, tokenString, mustVerify); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := o.validateClaims(tokenInfo); err != nil {
+		return nil, err
+	}
+
+	if o.cache != nil && !cached {
+		var expiresAt time.Time
+		if exp, _ := tokenInfo.Claims.GetExpirationTime(); exp != nil {
+			expiresAt = exp.Add(o.leeway)
+		}
+		o.cache.add(tokenString, tokenInfo, expiresAt)
+	}
+
+	if o.revocation != nil {
+		if err := checkRevoked(ctx, o.revocation, tokenInfo); err != nil {
+			return nil, err
+		}
+	}
+	return tokenInfo, nil
+}
+
+// verifyToken 解析 token 并验证签名
+func (o *options) verifyToken(ctx context.Context, tokenString string, mustVerify bool) (*jwt.Token, error) {
+	var tokenInfo *jwt.Token
+	var err error
+
+	if mustVerify {
+		var keyFunc jwt.Keyfunc
+		if o.keyFunc2 != nil {
+			keyFunc = func(t *jwt.Token) (interface{}, error) {
+				return o.keyFunc2(ctx, t)
 			}
-			return nil, ErrWrongContext
+		} else if o.keyFunc != nil {
+			keyFunc = o.keyFunc
 		}
+		// claims 由 validateClaims 统一验证，与未验证签名的模式保持一致
+		tokenInfo, err = jwt.NewParser(jwt.WithoutClaimsValidation()).
+			ParseWithClaims(tokenString, o.claims(), func(t *jwt.Token) (interface{}, error) {
+				if err := o.checkSigningMethod(t); err != nil {
+					return nil, err
+				}
+				return keyFunc(t)
+			})
+	} else {
+		tokenInfo, _, err = jwt.NewParser().ParseUnverified(tokenString, o.claims())
+		if err == nil {
+			err = o.checkSigningMethod(tokenInfo)
+		}
+	}
+	if err != nil {
+		return nil, parseError(err)
+	}
+
+	if mustVerify && !tokenInfo.Valid {
+		return nil, ErrTokenInvalid
 	}
+	return tokenInfo, nil
 }
 
 // NewContext put auth info into context
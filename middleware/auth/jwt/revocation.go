@@ -0,0 +1,55 @@
+package jwt
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/golang-jwt/jwt/v5"
+	authjwt "github.com/unkmonster/go-kit/auth/jwt"
+)
+
+var (
+	// ErrTokenRevoked token 已经被作废，与其他错误使用不同的 reason
+	ErrTokenRevoked = errors.Unauthorized("TOKEN_REVOKED", "JWT token has been revoked")
+	// ErrRevocationCheck 无法查询 token 是否被作废
+	ErrRevocationCheck = errors.ServiceUnavailable("REVOCATION_CHECK_FAILED", "Can not check whether token is revoked")
+)
+
+// WithRevocationStore 指定 Server 在解析 token 后检查其 jti 和 subject 是否被作废
+func WithRevocationStore(store authjwt.RevocationStore) Option {
+	return func(o *options) {
+		o.revocation = store
+	}
+}
+
+// checkRevoked 检查 token 是否被作废。
+// 自定义 claims 不一定能取到 jti，因此直接从 token 的 payload 中解析 registered claims
+func checkRevoked(ctx context.Context, store authjwt.RevocationStore, token *jwt.Token) error {
+	claims, err := registeredClaims(token)
+	if err != nil {
+		return ErrTokenParseFail.WithCause(err)
+	}
+
+	var issuedAt time.Time
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time
+	}
+	revoked, err := store.IsRevoked(ctx, claims.ID, claims.Subject, issuedAt)
+	if err != nil {
+		return ErrRevocationCheck.WithCause(err)
+	}
+	if revoked {
+		return ErrTokenRevoked
+	}
+	return nil
+}
+
+// registeredClaims 从 token 的 payload 中解析 registered claims
+func registeredClaims(token *jwt.Token) (*jwt.RegisteredClaims, error) {
+	claims := &jwt.RegisteredClaims{}
+	if err := decodePayload(token, claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
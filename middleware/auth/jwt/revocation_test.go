@@ -0,0 +1,71 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	authjwt "github.com/unkmonster/go-kit/auth/jwt"
+)
+
+type failingStore struct {
+	authjwt.RevocationStore
+}
+
+func (failingStore) IsRevoked(context.Context, string, string, time.Time) (bool, error) {
+	return false, errors.New("database is down")
+}
+
+func serveToken(mw func(ctx context.Context, req any) (any, error), tokenString string) error {
+	tr := newTestTransport()
+	tr.header.Set(authorizationKey, bearerWord+" "+tokenString)
+	_, err := mw(transport.NewServerContext(context.Background(), tr), nil)
+	return err
+}
+
+func TestServerRevocation(t *testing.T) {
+	key, err := authjwt.NewSigningKey("HS256")
+	require.NoError(t, err)
+	issuer := authjwt.NewIssuer(authjwt.StaticKey(key))
+	store := authjwt.NewMemoryRevocationStore(100)
+
+	mw := Server(
+		WithKeyFunc(authjwt.StaticKey(key).KeyFunc),
+		WithClaims(func() jwt.Claims { return jwt.MapClaims{} }),
+		WithRevocationStore(store),
+	)(func(ctx context.Context, req any) (any, error) {
+		return nil, nil
+	})
+
+	token, err := issuer.Issue("u1", nil)
+	require.NoError(t, err)
+	require.NoError(t, serveToken(mw, token.Token))
+
+	// 按 jti 作废
+	require.NoError(t, store.RevokeToken(context.Background(), token.ID, token.ExpiresAt))
+	err = serveToken(mw, token.Token)
+	require.Equal(t, ErrTokenRevoked, err)
+	require.False(t, kerrors.Is(err, ErrTokenInvalid))
+
+	// 按 subject 作废
+	other, err := issuer.Issue("u2", nil)
+	require.NoError(t, err)
+	require.NoError(t, store.RevokeSubject(context.Background(), "u2", time.Now(), time.Now().Add(time.Hour)))
+	require.Equal(t, ErrTokenRevoked, serveToken(mw, other.Token))
+
+	// 查询失败时拒绝请求
+	mw = Server(
+		WithKeyFunc(authjwt.StaticKey(key).KeyFunc),
+		WithRevocationStore(failingStore{}),
+	)(func(ctx context.Context, req any) (any, error) {
+		return nil, nil
+	})
+	token, err = issuer.Issue("u3", nil)
+	require.NoError(t, err)
+	require.True(t, kerrors.Is(serveToken(mw, token.Token), ErrRevocationCheck))
+}
@@ -0,0 +1,159 @@
+package jwt
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	// ErrInsufficientScope token 有效但缺少 operation 要求的 scope
+	ErrInsufficientScope = errors.Forbidden("INSUFFICIENT_SCOPE", "Token does not have the required scope")
+	// ErrInsufficientRole token 有效但不具有 operation 要求的任何角色
+	ErrInsufficientRole = errors.Forbidden("INSUFFICIENT_ROLE", "Token does not have the required role")
+)
+
+// AuthMode operation 的认证方式
+type AuthMode int
+
+const (
+	// AuthRequired 必须携带有效的 token
+	AuthRequired AuthMode = iota
+	// AuthOptional 可以不携带 token，携带时必须有效
+	AuthOptional
+	// AuthSkip 不检查 token
+	AuthSkip
+)
+
+// Matcher 判断规则是否适用于 operation
+type Matcher func(operation string) bool
+
+// Exact 匹配完全相同的 operation
+func Exact(operations ...string) Matcher {
+	return func(operation string) bool {
+		return slices.Contains(operations, operation)
+	}
+}
+
+// Prefix 匹配以 prefix 开头的 operation
+func Prefix(prefix string) Matcher {
+	return func(operation string) bool {
+		return strings.HasPrefix(operation, prefix)
+	}
+}
+
+// Regex 匹配符合正则表达式的 operation，表达式无效时 panic
+func Regex(expr string) Matcher {
+	re := regexp.MustCompile(expr)
+	return re.MatchString
+}
+
+type rule struct {
+	match  Matcher
+	mode   AuthMode
+	scopes []string
+	roles  []string
+}
+
+// RuleOption 配置 WithRule 添加的规则
+type RuleOption func(r *rule)
+
+// RequireScopes 要求 token 包含所有指定的 scope
+func RequireScopes(scopes ...string) RuleOption {
+	return func(r *rule) {
+		r.scopes = scopes
+	}
+}
+
+// RequireRoles 要求 token 至少具有其中一个角色
+func RequireRoles(roles ...string) RuleOption {
+	return func(r *rule) {
+		r.roles = roles
+	}
+}
+
+// WithRule 为匹配的 operation 指定认证方式，规则按添加顺序匹配，使用第一个匹配的规则。
+// 匹配规则的请求不受 WithPreventReq 影响，没有匹配任何规则的请求仍由 WithPreventReq 决定
+func WithRule(match Matcher, mode AuthMode, opts ...RuleOption) Option {
+	r := &rule{match: match, mode: mode}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return func(o *options) {
+		o.rules = append(o.rules, r)
+	}
+}
+
+// WithScopeClaim 指定保存 scope 的 claim，值可以是空格分隔的字符串或者字符串数组，默认为 scope
+func WithScopeClaim(name string) Option {
+	return func(o *options) {
+		o.scopeClaim = name
+	}
+}
+
+// WithRoleClaim 指定保存角色的 claim，值可以是空格分隔的字符串或者字符串数组，默认为 roles
+func WithRoleClaim(name string) Option {
+	return func(o *options) {
+		o.roleClaim = name
+	}
+}
+
+func (o *options) matchRule(operation string) *rule {
+	for _, r := range o.rules {
+		if r.match(operation) {
+			return r
+		}
+	}
+	return nil
+}
+
+// authorize 检查 token 是否具有规则要求的 scope 和角色
+func (r *rule) authorize(o *options, token *jwt.Token) error {
+	if len(r.scopes) == 0 && len(r.roles) == 0 {
+		return nil
+	}
+
+	payload := map[string]any{}
+	if err := decodePayload(token, &payload); err != nil {
+		return ErrTokenParseFail.WithCause(err)
+	}
+
+	if len(r.scopes) > 0 {
+		scopes := claimStrings(payload[o.scopeClaim])
+		for _, s := range r.scopes {
+			if !slices.Contains(scopes, s) {
+				return ErrInsufficientScope
+			}
+		}
+	}
+
+	if len(r.roles) > 0 {
+		roles := claimStrings(payload[o.roleClaim])
+		if !slices.ContainsFunc(r.roles, func(role string) bool {
+			return slices.Contains(roles, role)
+		}) {
+			return ErrInsufficientRole
+		}
+	}
+	return nil
+}
+
+// claimStrings 将空格分隔的字符串或者字符串数组转换为 []string
+func claimStrings(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		result := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
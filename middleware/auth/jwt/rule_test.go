@@ -0,0 +1,103 @@
+package jwt
+
+import (
+	"context"
+	"testing"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchers(t *testing.T) {
+	require.True(t, Exact("/a.B/C", "/a.B/D")("/a.B/D"))
+	require.False(t, Exact("/a.B/C")("/a.B/CD"))
+	require.True(t, Prefix("/a.B/")("/a.B/C"))
+	require.False(t, Prefix("/a.B/")("/a.C/C"))
+	require.True(t, Regex(`^/a\.B/(Get|List)`)("/a.B/ListUsers"))
+	require.False(t, Regex(`^/a\.B/(Get|List)`)("/a.B/Delete"))
+	require.Panics(t, func() { Regex("(") })
+}
+
+func TestServerRules(t *testing.T) {
+	var authenticated bool
+	mw := Server(
+		WithKeyFunc(testKeyProvider),
+		WithClaims(func() jwt.Claims { return jwt.MapClaims{} }),
+		WithRule(Exact("/api.Public/Health"), AuthSkip),
+		WithRule(Prefix("/api.Catalog/"), AuthOptional),
+		WithRule(Regex(`^/api\.Admin/`), AuthRequired, RequireRoles("admin", "root")),
+		WithRule(Prefix("/api.Orders/"), AuthRequired, RequireScopes("orders:read", "orders:write")),
+	)(func(ctx context.Context, req any) (any, error) {
+		_, authenticated = FromContext(ctx)
+		return nil, nil
+	})
+
+	call := func(operation, token string) error {
+		authenticated = false
+		tr := newTestTransport()
+		tr.operation = operation
+		if token != "" {
+			tr.header.Set(authorizationKey, bearerWord+" "+token)
+		}
+		_, err := mw(transport.NewServerContext(context.Background(), tr), nil)
+		return err
+	}
+
+	user := signHS256(t, jwt.MapClaims{"sub": "u1", "scope": "orders:read orders:write", "roles": []string{"user"}})
+	admin := signHS256(t, jwt.MapClaims{"sub": "u2", "scope": "orders:read", "roles": []string{"admin"}})
+
+	// 跳过认证，即使 token 无效
+	require.NoError(t, call("/api.Public/Health", "invalid"))
+	require.False(t, authenticated)
+
+	// 可选认证
+	require.NoError(t, call("/api.Catalog/List", ""))
+	require.False(t, authenticated)
+	require.NoError(t, call("/api.Catalog/List", user))
+	require.True(t, authenticated)
+	requireError(t, ErrTokenParseFail, call("/api.Catalog/List", "invalid"))
+
+	// 角色
+	require.NoError(t, call("/api.Admin/Ban", admin))
+	err := call("/api.Admin/Ban", user)
+	require.True(t, kerrors.IsForbidden(err))
+	require.True(t, kerrors.Is(err, ErrInsufficientRole))
+
+	// scope
+	require.NoError(t, call("/api.Orders/Create", user))
+	err = call("/api.Orders/Create", admin)
+	require.True(t, kerrors.Is(err, ErrInsufficientScope))
+	requireError(t, ErrMissingJwtToken, call("/api.Orders/Create", ""))
+	require.True(t, kerrors.IsUnauthorized(call("/api.Orders/Create", "")))
+
+	// 没有匹配的规则时默认要求认证
+	requireError(t, ErrMissingJwtToken, call("/api.Other/Call", ""))
+}
+
+func TestServerRulesWithPreventReq(t *testing.T) {
+	mw := Server(
+		WithKeyFunc(testKeyProvider),
+		WithPreventReq(false),
+		WithRule(Exact("/api.Private/Get"), AuthRequired),
+	)(func(ctx context.Context, req any) (any, error) {
+		return nil, nil
+	})
+
+	call := func(operation string) error {
+		tr := newTestTransport()
+		tr.operation = operation
+		_, err := mw(transport.NewServerContext(context.Background(), tr), nil)
+		return err
+	}
+
+	require.NoError(t, call("/api.Public/Get"))
+	requireError(t, ErrMissingJwtToken, call("/api.Private/Get"))
+}
+
+func TestClaimStrings(t *testing.T) {
+	require.Equal(t, []string{"a", "b"}, claimStrings("a  b"))
+	require.Equal(t, []string{"a", "b"}, claimStrings([]any{"a", 1, "b"}))
+	require.Nil(t, claimStrings(nil))
+}
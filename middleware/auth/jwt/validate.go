@@ -0,0 +1,154 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/golang-jwt/jwt/v5"
+	authjwt "github.com/unkmonster/go-kit/auth/jwt"
+)
+
+// errUnsupportedSigningMethod token 的算法不在 WithSigningMethods 指定的范围内
+var errUnsupportedSigningMethod = errors.New("signing method is not allowed")
+
+// WithIssuers 指定允许的 iss，token 的 iss 必须是其中之一
+func WithIssuers(issuers ...string) Option {
+	return func(o *options) {
+		o.issuers = issuers
+	}
+}
+
+// WithAudiences 指定允许的 aud，token 的 aud 至少包含其中之一
+func WithAudiences(audiences ...string) Option {
+	return func(o *options) {
+		o.audiences = audiences
+	}
+}
+
+// WithLeeway 指定验证 exp 和 nbf 时允许的时钟偏差
+func WithLeeway(leeway time.Duration) Option {
+	return func(o *options) {
+		o.leeway = leeway
+	}
+}
+
+// WithRequiredClaims 指定 token 必须包含的 claim，例如 exp、sub、jti
+func WithRequiredClaims(claims ...string) Option {
+	return func(o *options) {
+		o.requiredClaims = claims
+	}
+}
+
+// WithSigningMethods 指定允许的签名算法，例如 RS256，默认不限制
+func WithSigningMethods(algs ...string) Option {
+	return func(o *options) {
+		o.signingMethods = algs
+	}
+}
+
+func (o *options) checkSigningMethod(token *jwt.Token) error {
+	if len(o.signingMethods) == 0 || slices.Contains(o.signingMethods, token.Method.Alg()) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", errUnsupportedSigningMethod, token.Method.Alg())
+}
+
+// errRefreshToken token 是 authjwt.Issuer 签发的 refresh token
+var errRefreshToken = errors.New("refresh token can not be used as access token")
+
+// validateClaims 验证 exp、nbf、iss、aud、必需的 claim 以及自定义 claims 的 Validate，并拒绝 refresh token
+func (o *options) validateClaims(token *jwt.Token) error {
+	claims := token.Claims
+	now := time.Now()
+
+	exp, err := claims.GetExpirationTime()
+	if err != nil {
+		return ErrTokenInvalid.WithCause(err)
+	}
+	if exp != nil && !now.Before(exp.Add(o.leeway)) {
+		return ErrTokenExpired
+	}
+
+	nbf, err := claims.GetNotBefore()
+	if err != nil {
+		return ErrTokenInvalid.WithCause(err)
+	}
+	if nbf != nil && now.Before(nbf.Add(-o.leeway)) {
+		return ErrTokenInvalid.WithCause(jwt.ErrTokenNotValidYet)
+	}
+
+	if len(o.issuers) > 0 {
+		iss, err := claims.GetIssuer()
+		if err != nil || !slices.Contains(o.issuers, iss) {
+			return ErrTokenInvalid.WithCause(jwt.ErrTokenInvalidIssuer)
+		}
+	}
+
+	if len(o.audiences) > 0 {
+		aud, err := claims.GetAudience()
+		if err != nil || !slices.ContainsFunc(aud, func(a string) bool {
+			return slices.Contains(o.audiences, a)
+		}) {
+			return ErrTokenInvalid.WithCause(jwt.ErrTokenInvalidAudience)
+		}
+	}
+
+	payload := map[string]json.RawMessage{}
+	if err := decodePayload(token, &payload); err != nil {
+		return ErrTokenParseFail.WithCause(err)
+	}
+	for _, name := range o.requiredClaims {
+		if v, ok := payload[name]; !ok || string(v) == "null" {
+			return ErrTokenInvalid.WithCause(fmt.Errorf("%w: %s", jwt.ErrTokenRequiredClaimMissing, name))
+		}
+	}
+
+	// authjwt.Issuer 签发的 refresh token 与 access token 使用相同的密钥、iss 和 aud，
+	// 只能通过 token_type 区分，refresh token 只能用于 Issuer.Refresh
+	var tokenType string
+	if raw, ok := payload[authjwt.TokenTypeClaim]; ok {
+		_ = json.Unmarshal(raw, &tokenType)
+	}
+	if tokenType == authjwt.TokenTypeRefresh {
+		return ErrTokenInvalid.WithCause(errRefreshToken)
+	}
+
+	// 解析时关闭了 golang-jwt 的验证，自定义 claims 的 Validate 需要在这里调用
+	if v, ok := claims.(jwt.ClaimsValidator); ok {
+		if err := v.Validate(); err != nil {
+			return ErrTokenInvalid.WithCause(err)
+		}
+	}
+	return nil
+}
+
+// parseError 将解析 token 时的错误转换为对应的 Kratos 错误
+func parseError(err error) error {
+	switch {
+	case errors.Is(err, errUnsupportedSigningMethod):
+		return ErrUnSupportSigningMethod.WithCause(err)
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return ErrTokenParseFail.WithCause(err)
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid):
+		return ErrTokenInvalid.WithCause(err)
+	}
+	return kerrors.Unauthorized(reason, fmt.Sprintf("parse token: %s", err))
+}
+
+// decodePayload 将 token 的 payload 解析到 v
+func decodePayload(token *jwt.Token, v any) error {
+	parts := strings.Split(token.Raw, ".")
+	if len(parts) != 3 {
+		return jwt.ErrTokenMalformed
+	}
+	payload, err := jwt.NewParser().DecodeSegment(parts[1])
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(payload, v)
+}
@@ -0,0 +1,127 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	authjwt "github.com/unkmonster/go-kit/auth/jwt"
+)
+
+// requireError 比较错误的 message，因为这些错误使用相同的 reason，errors.Is 无法区分它们
+func requireError(t *testing.T, want *kerrors.Error, err error) {
+	t.Helper()
+	require.Error(t, err)
+	require.Equal(t, want.Message, kerrors.FromError(err).Message, err.Error())
+}
+
+func signHS256(t *testing.T, claims jwt.Claims) string {
+	t.Helper()
+	s, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(testKey)
+	require.NoError(t, err)
+	return s
+}
+
+func newServer(opts ...Option) func(ctx context.Context, req any) (any, error) {
+	return Server(opts...)(func(ctx context.Context, req any) (any, error) {
+		return nil, nil
+	})
+}
+
+func TestServerValidateClaims(t *testing.T) {
+	verified := WithKeyFunc(testKeyProvider)
+	now := time.Now()
+
+	for _, mode := range []struct {
+		name string
+		opts []Option
+	}{
+		{name: "verified", opts: []Option{verified}},
+		{name: "unverified"},
+	} {
+		server := func(opts ...Option) func(ctx context.Context, req any) (any, error) {
+			return newServer(append(opts, mode.opts...)...)
+		}
+
+		t.Run(mode.name, func(t *testing.T) {
+			expired := signHS256(t, jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(now.Add(-time.Minute))})
+			requireError(t, ErrTokenExpired, serveToken(server(), expired))
+			require.NoError(t, serveToken(server(WithLeeway(2*time.Minute)), expired))
+
+			notYet := signHS256(t, jwt.RegisteredClaims{NotBefore: jwt.NewNumericDate(now.Add(time.Minute))})
+			requireError(t, ErrTokenInvalid, serveToken(server(), notYet))
+			require.NoError(t, serveToken(server(WithLeeway(2*time.Minute)), notYet))
+
+			token := signHS256(t, jwt.RegisteredClaims{
+				Issuer:   "auth",
+				Audience: jwt.ClaimStrings{"api", "web"},
+				Subject:  "u1",
+			})
+			require.NoError(t, serveToken(server(WithIssuers("other", "auth"), WithAudiences("web")), token))
+			requireError(t, ErrTokenInvalid, serveToken(server(WithIssuers("other")), token))
+			requireError(t, ErrTokenInvalid, serveToken(server(WithAudiences("admin")), token))
+
+			require.NoError(t, serveToken(server(WithRequiredClaims("sub", "iss")), token))
+			requireError(t, ErrTokenInvalid, serveToken(server(WithRequiredClaims("exp")), token))
+
+			require.NoError(t, serveToken(server(WithSigningMethods("HS256")), token))
+			requireError(t, ErrUnSupportSigningMethod, serveToken(server(WithSigningMethods("RS256")), token))
+
+			requireError(t, ErrTokenParseFail, serveToken(server(), "not-a-token"))
+		})
+	}
+}
+
+func TestServerInvalidSignature(t *testing.T) {
+	other, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{}).SignedString([]byte("other"))
+	require.NoError(t, err)
+
+	requireError(t, ErrTokenInvalid, serveToken(newServer(WithKeyFunc(testKeyProvider)), other))
+	// 不验证签名时不受影响
+	require.NoError(t, serveToken(newServer(), other))
+}
+
+func TestServerRejectRefreshToken(t *testing.T) {
+	ring := authjwt.NewKeyRing(authjwt.WithKeyRingMethod("ES256"))
+	_, err := ring.Rotate()
+	require.NoError(t, err)
+	pair, err := authjwt.NewIssuer(ring, authjwt.WithIssuer("auth")).IssuePair("u1", nil)
+	require.NoError(t, err)
+
+	server := newServer(WithKeyFunc(ring.KeyFunc), WithIssuers("auth"))
+	require.NoError(t, serveToken(server, pair.Access.Token))
+	requireError(t, ErrTokenInvalid, serveToken(server, pair.Refresh.Token))
+}
+
+type tenantClaims struct {
+	jwt.RegisteredClaims
+	Tenant int64 `json:"tenant"`
+}
+
+// Validate implements jwt.ClaimsValidator.
+func (c *tenantClaims) Validate() error {
+	if c.Tenant == 0 {
+		return errors.New("missing tenant")
+	}
+	return nil
+}
+
+func TestServerClaimsValidator(t *testing.T) {
+	for _, mode := range []struct {
+		name string
+		opts []Option
+	}{
+		{name: "verified", opts: []Option{WithKeyFunc(testKeyProvider)}},
+		{name: "unverified"},
+	} {
+		t.Run(mode.name, func(t *testing.T) {
+			server := newServer(append(mode.opts, WithTypedClaims[tenantClaims]())...)
+			require.NoError(t, serveToken(server, signHS256(t, &tenantClaims{Tenant: 1})))
+			requireError(t, ErrTokenInvalid, serveToken(server, signHS256(t, &tenantClaims{})))
+		})
+	}
+}
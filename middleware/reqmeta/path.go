@@ -0,0 +1,144 @@
+package reqmeta
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// fieldPath 以点分隔的字段路径，例如 order.id。
+// 中间的字段必须为消息，可以是 repeated 消息；最后一个字段或者 oneof 的名称，
+// strict 时最后一个字段（oneof 中的所有字段）必须为整数或者字符串。
+// 路径上有 repeated 字段时值为 []any，否则为单个值，
+// 中间的消息或者 oneof 未设置时值为 nil
+type fieldPath struct {
+	path  string
+	steps []pathStep
+	// 路径上是否有 repeated 字段
+	repeated bool
+}
+
+type pathStep struct {
+	field protoreflect.FieldDescriptor
+	oneof protoreflect.OneofDescriptor
+}
+
+// compilePath 编译 desc 中的字段路径，strict 为 false 时不检查最后一个字段的类型
+func compilePath(desc protoreflect.MessageDescriptor, path string, strict bool) (*fieldPath, error) {
+	p := &fieldPath{path: path}
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		last := i == len(names)-1
+
+		fd := desc.Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			od := desc.Oneofs().ByName(protoreflect.Name(name))
+			if od == nil || od.IsSynthetic() {
+				return nil, fmt.Errorf("field %q not found in %s", name, desc.FullName())
+			}
+			if !last {
+				return nil, fmt.Errorf("oneof %q must be the last element of %q", name, path)
+			}
+			fields := od.Fields()
+			for j := 0; j < fields.Len() && strict; j++ {
+				if err := checkIdKind(fields.Get(j)); err != nil {
+					return nil, fmt.Errorf("oneof %q: %w", name, err)
+				}
+			}
+			p.steps = append(p.steps, pathStep{oneof: od})
+			break
+		}
+
+		if fd.IsMap() {
+			return nil, fmt.Errorf("map field %q is not supported", name)
+		}
+		if fd.IsList() {
+			p.repeated = true
+		}
+		p.steps = append(p.steps, pathStep{field: fd})
+
+		if last {
+			if strict {
+				if err := checkIdKind(fd); err != nil {
+					return nil, err
+				}
+			}
+			break
+		}
+		if !isMessage(fd) {
+			return nil, fmt.Errorf("field %q in %q is not a message", name, path)
+		}
+		desc = fd.Message()
+	}
+	return p, nil
+}
+
+// idKinds 可以作为 ID 的字段类型
+var idKinds = []protoreflect.Kind{
+	protoreflect.StringKind,
+	protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+	protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+	protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+	protoreflect.Uint64Kind, protoreflect.Fixed64Kind,
+}
+
+func checkIdKind(fd protoreflect.FieldDescriptor) error {
+	if !slices.Contains(idKinds, fd.Kind()) {
+		return fmt.Errorf("field %q of type %s can not be used as id", fd.Name(), fd.Kind())
+	}
+	return nil
+}
+
+func isMessage(fd protoreflect.FieldDescriptor) bool {
+	return fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind
+}
+
+// value 从 msg 中取出路径对应的值
+func (p *fieldPath) value(msg protoreflect.Message) any {
+	var values []any
+	p.collect(msg, 0, &values)
+	if p.repeated {
+		return values
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return values[0]
+}
+
+func (p *fieldPath) collect(msg protoreflect.Message, i int, values *[]any) {
+	step := p.steps[i]
+	if step.oneof != nil {
+		if fd := msg.WhichOneof(step.oneof); fd != nil {
+			*values = append(*values, msg.Get(fd).Interface())
+		}
+		return
+	}
+
+	fd := step.field
+	last := i == len(p.steps)-1
+	// 未设置的消息和 oneof 成员视为不存在，标量保持原来的行为返回零值
+	if (isMessage(fd) || fd.ContainingOneof() != nil) && !fd.IsList() && !msg.Has(fd) {
+		return
+	}
+
+	v := msg.Get(fd)
+	if fd.IsList() {
+		list := v.List()
+		for j := 0; j < list.Len(); j++ {
+			if last {
+				*values = append(*values, list.Get(j).Interface())
+			} else {
+				p.collect(list.Get(j).Message(), i+1, values)
+			}
+		}
+		return
+	}
+	if last {
+		*values = append(*values, v.Interface())
+		return
+	}
+	p.collect(v.Message(), i+1, values)
+}
@@ -0,0 +1,231 @@
+package reqmeta
+
+import (
+	"testing"
+
+	openapi_v3 "github.com/google/gnostic-models/openapiv3"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// schemaOptions 构造带有 openapi.v3.schema 注解的 MessageOptions，kv 为交替的名称和值
+func schemaOptions(kv ...string) *descriptorpb.MessageOptions {
+	s := &openapi_v3.Schema{}
+	for i := 0; i < len(kv); i += 2 {
+		s.SpecificationExtension = append(s.SpecificationExtension, &openapi_v3.NamedAny{
+			Name:  kv[i],
+			Value: &openapi_v3.Any{Yaml: kv[i+1]},
+		})
+	}
+	opts := &descriptorpb.MessageOptions{}
+	proto.SetExtension(opts, openapi_v3.E_Schema, s)
+	return opts
+}
+
+// operationOptions 构造带有 openapi.v3.operation 注解的 MethodOptions，kv 为交替的名称和值
+func operationOptions(kv ...string) *descriptorpb.MethodOptions {
+	op := &openapi_v3.Operation{}
+	for i := 0; i < len(kv); i += 2 {
+		op.SpecificationExtension = append(op.SpecificationExtension, &openapi_v3.NamedAny{
+			Name:  kv[i],
+			Value: &openapi_v3.Any{Yaml: kv[i+1]},
+		})
+	}
+	opts := &descriptorpb.MethodOptions{}
+	proto.SetExtension(opts, openapi_v3.E_Operation, op)
+	return opts
+}
+
+func field(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, opts ...func(*descriptorpb.FieldDescriptorProto)) *descriptorpb.FieldDescriptorProto {
+	f := &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String(name),
+		JsonName: proto.String(name),
+		Number:   proto.Int32(number),
+		Type:     typ.Enum(),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+func repeated(f *descriptorpb.FieldDescriptorProto) {
+	f.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+}
+
+func message(name string) func(*descriptorpb.FieldDescriptorProto) {
+	return func(f *descriptorpb.FieldDescriptorProto) {
+		f.TypeName = proto.String(".test." + name)
+	}
+}
+
+func inOneof(index int32) func(*descriptorpb.FieldDescriptorProto) {
+	return func(f *descriptorpb.FieldDescriptorProto) {
+		f.OneofIndex = proto.Int32(index)
+	}
+}
+
+const (
+	int64Type   = descriptorpb.FieldDescriptorProto_TYPE_INT64
+	stringType  = descriptorpb.FieldDescriptorProto_TYPE_STRING
+	messageType = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+)
+
+// testFile 构造用于测试字段路径的消息
+func testFile(t *testing.T) protoreflect.FileDescriptor {
+	t.Helper()
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("reqmeta_path_test.proto"),
+		Package: proto.String("test"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Order"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("id", 1, int64Type),
+					field("user_id", 2, stringType),
+				},
+			},
+			{
+				Name:    proto.String("NestedRequest"),
+				Options: schemaOptions(resourceTypeKey, "order", resourceIdFieldKey, "order.id", ownerIdFieldKey, "order.user_id"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("order", 1, messageType, message("Order")),
+				},
+			},
+			{
+				Name:    proto.String("BatchRequest"),
+				Options: schemaOptions(resourceTypeKey, "order", resourceIdFieldKey, "ids", ownerIdFieldKey, "orders.user_id"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("ids", 1, int64Type, repeated),
+					field("orders", 2, messageType, message("Order"), repeated),
+				},
+			},
+			{
+				Name:    proto.String("OneofRequest"),
+				Options: schemaOptions(resourceTypeKey, "order", resourceIdFieldKey, "target", ownerIdFieldKey, "order.user_id"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("id", 1, int64Type, inOneof(0)),
+					field("name", 2, stringType, inOneof(0)),
+					field("order", 3, messageType, message("Order")),
+				},
+				OneofDecl: []*descriptorpb.OneofDescriptorProto{{Name: proto.String("target")}},
+			},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{
+			{
+				Name: proto.String("OrderService"),
+				Method: []*descriptorpb.MethodDescriptorProto{
+					{
+						Name:       proto.String("Get"),
+						InputType:  proto.String(".test.NestedRequest"),
+						OutputType: proto.String(".test.Order"),
+					},
+					{
+						Name:       proto.String("Transfer"),
+						InputType:  proto.String(".test.NestedRequest"),
+						OutputType: proto.String(".test.Order"),
+						Options:    operationOptions(actionKey, "TRANSFER", selfHoldKey, "true", ownerTypeKey, "user"),
+					},
+					{
+						Name:       proto.String("Batch"),
+						InputType:  proto.String(".test.BatchRequest"),
+						OutputType: proto.String(".test.Order"),
+						Options:    operationOptions(resourceIdFieldKey, "orders.id"),
+					},
+				},
+			},
+		},
+	}
+	fd, err := protodesc.NewFile(fdp, nil)
+	require.NoError(t, err)
+	return fd
+}
+
+func newMessage(fd protoreflect.FileDescriptor, name string) *dynamicpb.Message {
+	return dynamicpb.NewMessage(fd.Messages().ByName(protoreflect.Name(name)))
+}
+
+func TestNestedPath(t *testing.T) {
+	fd := testFile(t)
+
+	msg := newMessage(fd, "NestedRequest")
+	res := parseMessage(msg)
+	// 中间的消息未设置
+	require.Nil(t, res.ResourceId)
+	require.Nil(t, res.OwnerId)
+
+	order := newMessage(fd, "Order")
+	order.Set(order.Descriptor().Fields().ByName("id"), protoreflect.ValueOfInt64(5))
+	order.Set(order.Descriptor().Fields().ByName("user_id"), protoreflect.ValueOfString("u1"))
+	msg.Set(msg.Descriptor().Fields().ByName("order"), protoreflect.ValueOfMessage(order))
+
+	res = parseMessage(msg)
+	require.Equal(t, int64(5), res.ResourceId)
+	require.Equal(t, "u1", res.OwnerId)
+}
+
+func TestRepeatedPath(t *testing.T) {
+	fd := testFile(t)
+	msg := newMessage(fd, "BatchRequest")
+
+	res := parseMessage(msg)
+	require.Empty(t, res.ResourceId)
+
+	ids := msg.Mutable(msg.Descriptor().Fields().ByName("ids")).List()
+	ids.Append(protoreflect.ValueOfInt64(1))
+	ids.Append(protoreflect.ValueOfInt64(2))
+	orders := msg.Mutable(msg.Descriptor().Fields().ByName("orders")).List()
+	for _, user := range []string{"u1", "u2"} {
+		order := newMessage(fd, "Order")
+		order.Set(order.Descriptor().Fields().ByName("user_id"), protoreflect.ValueOfString(user))
+		orders.Append(protoreflect.ValueOfMessage(order))
+	}
+
+	res = parseMessage(msg)
+	require.Equal(t, []any{int64(1), int64(2)}, res.ResourceId)
+	require.Equal(t, []any{"u1", "u2"}, res.OwnerId)
+}
+
+func TestOneofPath(t *testing.T) {
+	fd := testFile(t)
+	msg := newMessage(fd, "OneofRequest")
+
+	res := parseMessage(msg)
+	require.Nil(t, res.ResourceId)
+
+	msg.Set(msg.Descriptor().Fields().ByName("name"), protoreflect.ValueOfString("latest"))
+	res = parseMessage(msg)
+	require.Equal(t, "latest", res.ResourceId)
+
+	msg.Set(msg.Descriptor().Fields().ByName("id"), protoreflect.ValueOfInt64(7))
+	res = parseMessage(msg)
+	require.Equal(t, int64(7), res.ResourceId)
+}
+
+func TestCompilePathError(t *testing.T) {
+	fd := testFile(t)
+	desc := fd.Messages().ByName("OneofRequest")
+
+	for _, path := range []string{
+		"missing",
+		"order.missing",
+		"id.value",
+		"target.id",
+		"",
+	} {
+		_, err := compilePath(desc, path, false)
+		require.Error(t, err, path)
+	}
+
+	// 字段类型只在 strict 时检查
+	_, err := compilePath(desc, "order", true)
+	require.ErrorContains(t, err, `field "order" of type message can not be used as id`)
+	_, err = compilePath(desc, "order", false)
+	require.NoError(t, err)
+}
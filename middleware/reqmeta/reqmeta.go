@@ -2,14 +2,16 @@ package reqmeta
 
 import (
 	"context"
+	"errors"
 	"strings"
+	"sync"
 
 	"github.com/go-kratos/kratos/v2/middleware"
 	"github.com/go-kratos/kratos/v2/transport"
 	"github.com/go-kratos/kratos/v2/transport/http"
-	openapi_v3 "github.com/google/gnostic-models/openapiv3"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
 )
 
 const (
@@ -41,6 +43,9 @@ type Resource struct {
 
 type options struct {
 	attributes map[string]string
+	files      *protoregistry.Files
+	// operation 到 RPC 方法的缓存
+	methods sync.Map
 }
 
 type Option func(o *options)
@@ -52,81 +57,127 @@ func WithAttributes(attributes map[string]string) Option {
 	}
 }
 
-// 从 proto message 提取请求元数据并注入到上下文
+// WithFiles 指定查找 RPC 方法描述的 registry，默认为 protoregistry.GlobalFiles
+func WithFiles(files *protoregistry.Files) Option {
+	return func(o *options) {
+		o.files = files
+	}
+}
+
+// registry 返回查找 RPC 方法描述的 registry
+func (o *options) registry() *protoregistry.Files {
+	if o.files == nil {
+		return protoregistry.GlobalFiles
+	}
+	return o.files
+}
+
+// 从 proto message 提取请求元数据并注入到上下文。
+// 除了消息上的 openapi.v3.schema 注解，还会读取 RPC 方法上的 openapi.v3.operation 注解，
+// 方法通过 Kratos 的 operation 查找，方法上的值覆盖消息上的值
 func Server(opts ...Option) middleware.Middleware {
-	options := &options{
+	return newOptions(opts).handle
+}
+
+// NewServer 与 Server 相同，但是通过 WithFiles 指定了 registry 时会先用 ValidateMethods 检查其中的 RPC 方法，
+// 有错误时返回错误。没有指定时不检查 protoregistry.GlobalFiles，其中可能包含依赖引入、当前服务并不处理的方法
+func NewServer(opts ...Option) (middleware.Middleware, error) {
+	o := newOptions(opts)
+	if o.files != nil {
+		if err := ValidateMethods(o.files); err != nil {
+			return nil, err
+		}
+	}
+	return o.handle, nil
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
 		attributes: nil,
 	}
 	for _, opt := range opts {
-		opt(options)
+		opt(o)
 	}
+	return o
+}
 
-	return func(h middleware.Handler) middleware.Handler {
-		return func(ctx context.Context, req any) (any, error) {
-			msg, ok := req.(proto.Message)
-			if !ok {
-				return h(ctx, req)
-			}
+func (o *options) handle(h middleware.Handler) middleware.Handler {
+	return func(ctx context.Context, req any) (any, error) {
+		msg, ok := req.(proto.Message)
+		if !ok {
+			return h(ctx, req)
+		}
 
-			meta, err := parseMessage(msg)
-			if err != nil {
-				return nil, err
-			}
+		tr, hasTransport := transport.FromServerContext(ctx)
+		var method protoreflect.MethodDescriptor
+		if hasTransport {
+			method = o.findMethod(tr.Operation())
+		}
+
+		meta := parseRequest(method, msg)
 
-			// 使用标准方法作为 action 的默认值
-			if meta.Action == "" {
-				if tr, ok := transport.FromServerContext(ctx); ok {
-					if htr, ok := tr.(http.Transporter); ok {
-						meta.Action = htr.Request().Method
-					}
-				}
+		// 使用标准方法作为 action 的默认值
+		if meta.Action == "" && hasTransport {
+			if htr, ok := tr.(http.Transporter); ok {
+				meta.Action = htr.Request().Method
 			}
-			ctx = NewContext(ctx, meta)
-			return h(ctx, req)
 		}
+		ctx = NewContext(ctx, meta)
+		return h(ctx, req)
 	}
 }
 
-func parseMessage(msg proto.Message) (Resource, error) {
-	desc := msg.ProtoReflect().Descriptor()
-	schema := proto.GetExtension(desc.Options(), openapi_v3.E_Schema).(*openapi_v3.Schema)
-
-	var (
-		resourceIdField string
-		ownerIdField    string
-	)
-
-	result := Resource{}
-	for _, ext := range schema.GetSpecificationExtension() {
-		value := ext.Value.Yaml
-		switch ext.Name {
-		case resourceTypeKey:
-			result.ResourceType = value
-		case resourceIdFieldKey:
-			resourceIdField = value
-		case actionKey:
-			result.Action = value
-		case selfHoldKey:
-			result.IsSelfHold = strings.EqualFold("true", value)
-		case resourceCollectionKey:
-			result.IsCollection = strings.EqualFold("true", value)
-		case ownerIdFieldKey:
-			ownerIdField = value
-		case ownerTypeKey:
-			result.OwnerType = value
-		}
+func parseMessage(msg proto.Message) Resource {
+	return parseRequest(nil, msg)
+}
+
+// parseRequest 根据 method 和 msg 上的注解提取资源，method 可以为 nil
+func parseRequest(method protoreflect.MethodDescriptor, msg proto.Message) Resource {
+	return loadSchema(method, msg.ProtoReflect().Descriptor()).extract(msg.ProtoReflect())
+}
+
+type methodEntry struct {
+	method protoreflect.MethodDescriptor
+}
+
+// findMethod 带缓存的 findMethod
+func (o *options) findMethod(operation string) protoreflect.MethodDescriptor {
+	if v, ok := o.methods.Load(operation); ok {
+		return v.(methodEntry).method
 	}
+	method := findMethod(o.registry(), operation)
+	o.methods.Store(operation, methodEntry{method: method})
+	return method
+}
 
-	msgReflect := msg.ProtoReflect()
-	field := msgReflect.Descriptor().Fields().ByName(protoreflect.Name(resourceIdField))
-	if field != nil {
-		result.ResourceId = msgReflect.Get(field).Interface()
+// findMethod 根据 Kratos operation（/package.Service/Method）查找 RPC 方法，找不到时返回 nil
+func findMethod(files *protoregistry.Files, operation string) protoreflect.MethodDescriptor {
+	service, name, ok := strings.Cut(strings.TrimPrefix(operation, "/"), "/")
+	if !ok {
+		return nil
+	}
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil
 	}
-	field = msgReflect.Descriptor().Fields().ByName(protoreflect.Name(ownerIdField))
-	if field != nil {
-		result.OwnerId = msgReflect.Get(field).Interface()
+	sd, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil
+	}
+	return sd.Methods().ByName(protoreflect.Name(name))
+}
+
+// Check 与 Validate 一样检查 msgs 的注解，未知的注解、错误的布尔值、不存在或者不能作为 ID 的字段都会返回错误。
+// Server 在请求时忽略这些错误（无法解析的字段视为不存在），应当在启动时对请求消息调用 Check 发现它们。
+// 需要检查 registry 中的所有消息和方法时使用 Validate
+func Check(msgs ...proto.Message) error {
+	var errs []error
+	for _, msg := range msgs {
+		if _, err := parseSchema(nil, msg.ProtoReflect().Descriptor(), true); err != nil {
+			errs = append(errs, err)
+		}
 	}
-	return result, nil
+	return errors.Join(errs...)
 }
 
 type resKey struct{}
@@ -1,9 +1,16 @@
 package reqmeta
 
 import (
+	"context"
 	"testing"
 
+	"github.com/go-kratos/kratos/v2/transport"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
 )
 
 func TestParseMessage(t *testing.T) {
@@ -12,8 +19,7 @@ func TestParseMessage(t *testing.T) {
 		UserId: 250,
 	}
 
-	res, err := parseMessage(msg)
-	require.NoError(t, err)
+	res := parseMessage(msg)
 
 	require.Equal(t, "order", res.ResourceType)
 	require.Equal(t, msg.Id, res.ResourceId)
@@ -26,8 +32,124 @@ func TestParseMessage(t *testing.T) {
 
 func TestMissingIdField(t *testing.T) {
 	msg := &MissingIdField{}
-	res, err := parseMessage(msg)
+	// 请求时无法解析的字段视为不存在，与之前的版本一致
+	res := parseMessage(msg)
+	require.Equal(t, "order", res.ResourceType)
+	require.Nil(t, res.ResourceId)
+
+	require.Error(t, Check(&ExampleRequest{}, msg))
+	require.NoError(t, Check(&ExampleRequest{}))
+}
+
+type testTransport struct {
+	operation string
+}
+
+func (t *testTransport) Kind() transport.Kind            { return transport.KindGRPC }
+func (t *testTransport) Endpoint() string                { return "" }
+func (t *testTransport) Operation() string               { return t.operation }
+func (t *testTransport) RequestHeader() transport.Header { return nil }
+func (t *testTransport) ReplyHeader() transport.Header   { return nil }
+
+func serve(t *testing.T, files *protoregistry.Files, operation string, req proto.Message) (Resource, error) {
+	t.Helper()
+	var res Resource
+	ctx := transport.NewServerContext(context.Background(), &testTransport{operation: operation})
+	_, err := Server(WithFiles(files))(func(ctx context.Context, req any) (any, error) {
+		res, _ = FromContext(ctx)
+		return nil, nil
+	})(ctx, req)
+	return res, err
+}
+
+func TestMethodAnnotations(t *testing.T) {
+	fd := testFile(t)
+	files := new(protoregistry.Files)
+	require.NoError(t, files.RegisterFile(fd))
+
+	order := newMessage(fd, "Order")
+	order.Set(order.Descriptor().Fields().ByName("id"), protoreflect.ValueOfInt64(5))
+	order.Set(order.Descriptor().Fields().ByName("user_id"), protoreflect.ValueOfString("u1"))
+	msg := newMessage(fd, "NestedRequest")
+	msg.Set(msg.Descriptor().Fields().ByName("order"), protoreflect.ValueOfMessage(order))
+
+	// 方法上没有注解时使用消息上的注解
+	res, err := serve(t, files, "/test.OrderService/Get", msg)
 	require.NoError(t, err)
+	require.Equal(t, "order", res.ResourceType)
+	require.Empty(t, res.Action)
+	require.False(t, res.IsSelfHold)
 
-	require.Empty(t, res.ResourceId)
+	// 方法上的注解覆盖消息上的注解
+	res, err = serve(t, files, "/test.OrderService/Transfer", msg)
+	require.NoError(t, err)
+	require.Equal(t, "order", res.ResourceType)
+	require.Equal(t, "TRANSFER", res.Action)
+	require.True(t, res.IsSelfHold)
+	require.Equal(t, "user", res.OwnerType)
+	require.Equal(t, int64(5), res.ResourceId)
+	require.Equal(t, "u1", res.OwnerId)
+
+	// 方法上的字段路径基于请求消息编译
+	batch := newMessage(fd, "BatchRequest")
+	orders := batch.Mutable(batch.Descriptor().Fields().ByName("orders")).List()
+	orders.Append(protoreflect.ValueOfMessage(order))
+	res, err = serve(t, files, "/test.OrderService/Batch", batch)
+	require.NoError(t, err)
+	require.Equal(t, []any{int64(5)}, res.ResourceId)
+
+	// 找不到方法或者方法的输入不是请求消息时只使用消息上的注解
+	res, err = serve(t, files, "/test.OrderService/Unknown", msg)
+	require.NoError(t, err)
+	require.Empty(t, res.Action)
+	res, err = serve(t, files, "/test.OrderService/Batch", msg)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), res.ResourceId)
+}
+
+func TestValidateMethods(t *testing.T) {
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("reqmeta_check_test.proto"),
+		Package: proto.String("check"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name:    proto.String("Request"),
+				Options: schemaOptions(resourceTypeKey, "order", ownerIdFieldKey, "owner"),
+			},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{
+			{
+				Name: proto.String("Service"),
+				Method: []*descriptorpb.MethodDescriptorProto{
+					{
+						Name:       proto.String("Get"),
+						InputType:  proto.String(".check.Request"),
+						OutputType: proto.String(".check.Request"),
+					},
+				},
+			},
+		},
+	}
+	fd, err := protodesc.NewFile(fdp, nil)
+	require.NoError(t, err)
+	files := new(protoregistry.Files)
+	require.NoError(t, files.RegisterFile(fd))
+
+	// 不再在创建 Server 时检查，由调用者决定检查哪些方法
+	require.NotPanics(t, func() { Server(WithFiles(files)) })
+	err = ValidateMethods(files)
+	require.EqualError(t, err, `check.Service.Get: x-resource-owner-id-field: field "owner" not found in check.Request`)
+	_, err = NewServer(WithFiles(files))
+	require.EqualError(t, err, ValidateMethods(files).Error())
+
+	valid := new(protoregistry.Files)
+	require.NoError(t, valid.RegisterFile(testFile(t)))
+	require.NoError(t, ValidateMethods(valid))
+	_, err = NewServer(WithFiles(valid))
+	require.NoError(t, err)
+
+	// 没有 WithFiles 时不检查 protoregistry.GlobalFiles
+	_, err = NewServer()
+	require.NoError(t, err)
 }
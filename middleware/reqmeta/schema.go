@@ -0,0 +1,216 @@
+package reqmeta
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/go-kratos/kratos/v2/log"
+	openapi_v3 "github.com/google/gnostic-models/openapiv3"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// 所有注解名称的前缀，Validate 将使用该前缀的未知注解视为错误
+const keyPrefix = "x-resource-"
+
+// schema 从消息注解中解析出的资源定义
+type schema struct {
+	resource   Resource
+	resourceId *fieldPath
+	ownerId    *fieldPath
+}
+
+type schemaKey struct {
+	method protoreflect.MethodDescriptor
+	desc   protoreflect.MessageDescriptor
+}
+
+// schemaCache 缓存每个消息（以及方法）解析出的 schema
+var schemaCache sync.Map
+
+// loadSchema 带缓存的非严格模式的 parseSchema，不会失败
+func loadSchema(method protoreflect.MethodDescriptor, desc protoreflect.MessageDescriptor) *schema {
+	if method != nil && method.Input().FullName() != desc.FullName() {
+		method = nil
+	}
+	key := schemaKey{method: method, desc: desc}
+	if v, ok := schemaCache.Load(key); ok {
+		return v.(*schema)
+	}
+	s, _ := parseSchema(method, desc, false)
+	v, _ := schemaCache.LoadOrStore(key, s)
+	return v.(*schema)
+}
+
+func messageExtensions(desc protoreflect.MessageDescriptor) []*openapi_v3.NamedAny {
+	s := proto.GetExtension(desc.Options(), openapi_v3.E_Schema).(*openapi_v3.Schema)
+	return s.GetSpecificationExtension()
+}
+
+func methodExtensions(method protoreflect.MethodDescriptor) []*openapi_v3.NamedAny {
+	op := proto.GetExtension(method.Options(), openapi_v3.E_Operation).(*openapi_v3.Operation)
+	return op.GetSpecificationExtension()
+}
+
+// parseSchema 解析 desc 上的 openapi.v3.schema 注解，
+// method 不为 nil 时再解析其 openapi.v3.operation 注解并覆盖消息上的值。
+// strict 为 true 时未知的 x-resource-* 注解、错误的布尔值、不存在或者不能作为 ID 的字段都会返回错误；
+// 否则与之前的版本一致忽略未知的注解，只有 true（不区分大小写）被视为真，
+// 无法解析的字段路径记录日志后视为不存在，不会返回错误
+func parseSchema(method protoreflect.MethodDescriptor, desc protoreflect.MessageDescriptor, strict bool) (*schema, error) {
+	name := desc.FullName()
+	exts := messageExtensions(desc)
+	// 只有 method 的输入就是 desc 时才使用方法上的注解
+	if method != nil && method.Input().FullName() == desc.FullName() {
+		name = method.FullName()
+		// 后出现的值覆盖先出现的值
+		exts = append(slices.Clip(exts), methodExtensions(method)...)
+	}
+
+	var (
+		resourceIdField string
+		ownerIdField    string
+		errs            []error
+	)
+
+	s := &schema{}
+	for _, ext := range exts {
+		value := ext.GetValue().GetYaml()
+		switch ext.Name {
+		case resourceTypeKey:
+			s.resource.ResourceType = value
+		case resourceIdFieldKey:
+			resourceIdField = value
+		case actionKey:
+			s.resource.Action = value
+		case selfHoldKey:
+			s.resource.IsSelfHold = parseBool(ext.Name, value, strict, &errs)
+		case resourceCollectionKey:
+			s.resource.IsCollection = parseBool(ext.Name, value, strict, &errs)
+		case ownerIdFieldKey:
+			ownerIdField = value
+		case ownerTypeKey:
+			s.resource.OwnerType = value
+		default:
+			if strict && strings.HasPrefix(ext.Name, keyPrefix) {
+				errs = append(errs, fmt.Errorf("unknown annotation %s", ext.Name))
+			}
+		}
+	}
+
+	var err error
+	if resourceIdField != "" {
+		if s.resourceId, err = compilePath(desc, resourceIdField, strict); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", resourceIdFieldKey, err))
+		}
+	}
+	if ownerIdField != "" {
+		if s.ownerId, err = compilePath(desc, ownerIdField, strict); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ownerIdFieldKey, err))
+		}
+	}
+	if len(errs) > 0 {
+		err := fmt.Errorf("%s: %w", name, errors.Join(errs...))
+		if strict {
+			return nil, err
+		}
+		log.Warnf("reqmeta: ignore invalid resource annotation: %v", err)
+	}
+	return s, nil
+}
+
+func parseBool(key, value string, strict bool, errs *[]error) bool {
+	if strings.EqualFold(value, "true") {
+		return true
+	}
+	if strict && !strings.EqualFold(value, "false") {
+		*errs = append(*errs, fmt.Errorf("%s: invalid bool %q", key, value))
+	}
+	return false
+}
+
+func (s *schema) extract(msg protoreflect.Message) Resource {
+	result := s.resource
+	if s.resourceId != nil {
+		result.ResourceId = s.resourceId.value(msg)
+	}
+	if s.ownerId != nil {
+		result.OwnerId = s.ownerId.value(msg)
+	}
+	return result
+}
+
+// hasResourceKeys 判断注解中是否有 x-resource-* 注解
+func hasResourceKeys(exts []*openapi_v3.NamedAny) bool {
+	return slices.ContainsFunc(exts, func(ext *openapi_v3.NamedAny) bool {
+		return strings.HasPrefix(ext.Name, keyPrefix)
+	})
+}
+
+// Validate 检查 files 中所有带有 x-resource-* 注解的消息和 RPC 方法，
+// 报告未知的注解、不存在的字段以及类型不能作为 ID 的字段，
+// 通常在启动时或者单元测试中对 protoregistry.GlobalFiles 调用，让错误的注解在 CI 中失败
+func Validate(files *protoregistry.Files) error {
+	var errs []error
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		errs = append(errs, validateMessages(fd.Messages())...)
+
+		services := fd.Services()
+		for i := 0; i < services.Len(); i++ {
+			methods := services.Get(i).Methods()
+			for j := 0; j < methods.Len(); j++ {
+				method := methods.Get(j)
+				if !hasResourceKeys(methodExtensions(method)) {
+					continue
+				}
+				if _, err := parseSchema(method, method.Input(), true); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+		return true
+	})
+	return errors.Join(errs...)
+}
+
+// ValidateMethods 与 Validate 相同，但是只检查 files 中 RPC 方法的请求消息以及方法上的注解，
+// 方法和请求消息都没有 x-resource-* 注解时不检查。通常在启动时对服务注册的 proto 调用，
+// 或者通过 NewServer 对 WithFiles 指定的 registry 调用
+func ValidateMethods(files *protoregistry.Files) error {
+	var errs []error
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		services := fd.Services()
+		for i := 0; i < services.Len(); i++ {
+			methods := services.Get(i).Methods()
+			for j := 0; j < methods.Len(); j++ {
+				method := methods.Get(j)
+				if !hasResourceKeys(methodExtensions(method)) && !hasResourceKeys(messageExtensions(method.Input())) {
+					continue
+				}
+				if _, err := parseSchema(method, method.Input(), true); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+		return true
+	})
+	return errors.Join(errs...)
+}
+
+func validateMessages(msgs protoreflect.MessageDescriptors) []error {
+	var errs []error
+	for i := 0; i < msgs.Len(); i++ {
+		desc := msgs.Get(i)
+		if hasResourceKeys(messageExtensions(desc)) {
+			if _, err := parseSchema(nil, desc, true); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		errs = append(errs, validateMessages(desc.Messages())...)
+	}
+	return errs
+}
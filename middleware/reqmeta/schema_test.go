@@ -0,0 +1,109 @@
+package reqmeta
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestSchemaCache(t *testing.T) {
+	desc := (&ExampleRequest{}).ProtoReflect().Descriptor()
+	s1 := loadSchema(nil, desc)
+	s2 := loadSchema(nil, desc)
+	require.Same(t, s1, s2)
+
+	// 请求时无法解析的字段视为不存在，结果同样被缓存
+	desc = (&MissingIdField{}).ProtoReflect().Descriptor()
+	s1 = loadSchema(nil, desc)
+	require.Nil(t, s1.resourceId)
+	require.Same(t, s1, loadSchema(nil, desc))
+}
+
+func TestValidate(t *testing.T) {
+	files := new(protoregistry.Files)
+	require.NoError(t, files.RegisterFile(testFile(t)))
+	require.NoError(t, Validate(files))
+
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("reqmeta_validate_test.proto"),
+		Package: proto.String("bad"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name:    proto.String("UnknownKey"),
+				Options: schemaOptions(resourceTypeKey, "order", "x-resource-typo", "x"),
+			},
+			{
+				Name:    proto.String("BadBool"),
+				Options: schemaOptions(selfHoldKey, "yes"),
+			},
+			{
+				Name:    proto.String("TypeMismatch"),
+				Options: schemaOptions(resourceIdFieldKey, "price"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("price", 1, descriptorpb.FieldDescriptorProto_TYPE_DOUBLE),
+				},
+			},
+			{
+				Name: proto.String("Outer"),
+				NestedType: []*descriptorpb.DescriptorProto{
+					{
+						Name:    proto.String("Inner"),
+						Options: schemaOptions(ownerIdFieldKey, "missing"),
+					},
+				},
+			},
+			{
+				// 没有 x-resource-* 注解的消息不检查
+				Name: proto.String("Plain"),
+			},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{
+			{
+				Name: proto.String("Service"),
+				Method: []*descriptorpb.MethodDescriptorProto{
+					{
+						Name:       proto.String("Remove"),
+						InputType:  proto.String(".bad.Plain"),
+						OutputType: proto.String(".bad.Plain"),
+						Options:    operationOptions(resourceIdFieldKey, "id"),
+					},
+				},
+			},
+		},
+	}
+	fd, err := protodesc.NewFile(fdp, nil)
+	require.NoError(t, err)
+	require.NoError(t, files.RegisterFile(fd))
+
+	err = Validate(files)
+	require.ErrorContains(t, err, "bad.UnknownKey: unknown annotation x-resource-typo")
+	require.ErrorContains(t, err, `bad.BadBool: x-resource-self-hold: invalid bool "yes"`)
+	require.ErrorContains(t, err, `bad.TypeMismatch: x-resource-id-field: field "price" of type double can not be used as id`)
+	require.ErrorContains(t, err, `bad.Outer.Inner: x-resource-owner-id-field: field "missing" not found`)
+	require.ErrorContains(t, err, `bad.Service.Remove: x-resource-id-field: field "id" not found`)
+	require.NotContains(t, err.Error(), "bad.Plain:")
+
+	// 请求时与之前的版本一致，忽略未知的注解、错误的布尔值以及无法解析的字段
+	res := parseMessage(dynamicpb.NewMessage(fd.Messages().ByName("UnknownKey")))
+	require.Equal(t, "order", res.ResourceType)
+	res = parseMessage(dynamicpb.NewMessage(fd.Messages().ByName("BadBool")))
+	require.False(t, res.IsSelfHold)
+	// 字段类型只在 Validate 中检查，请求时照常取值
+	res = parseMessage(dynamicpb.NewMessage(fd.Messages().ByName("TypeMismatch")))
+	require.Equal(t, float64(0), res.ResourceId)
+	res = parseMessage(dynamicpb.NewMessage(fd.Messages().ByName("Outer").Messages().ByName("Inner")))
+	require.Nil(t, res.OwnerId)
+}
+
+func TestValidateGlobalFiles(t *testing.T) {
+	// example.proto 中的 MissingIdField 故意使用了不存在的字段
+	err := Validate(protoregistry.GlobalFiles)
+	require.ErrorContains(t, err, "example.MissingIdField")
+	require.NotContains(t, err.Error(), "example.ExampleRequest")
+}